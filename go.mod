@@ -10,5 +10,5 @@ require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/launchdarkly/go-jsonstream/v3 v3.1.0
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,64 @@
+// Command parcel inspects and validates trees of parcel assets.
+//
+//	parcel -fs base:10 -fs mods:0 validate
+//
+// By default the Parcel is set up from parcel.json in the current
+// directory, or the manifest named by -manifest.  Otherwise each -fs
+// flag registers a directory with RegisterFS at an optional priority,
+// so assets are found the same way the runtime finds them.
+// See package parcelcli for the commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/Bradbev/parcel/src/parcelcli"
+)
+
+type fsFlags []string
+
+func (f *fsFlags) String() string     { return strings.Join(*f, ",") }
+func (f *fsFlags) Set(v string) error { *f = append(*f, v); return nil }
+
+func main() {
+	var dirs fsFlags
+	flag.Var(&dirs, "fs", "directory to read assets from, as dir or dir:priority (repeatable)")
+	manifest := flag.String("manifest", "", "manifest to set up from (default "+parcel.ManifestFileName+" if it exists)")
+	flag.Parse()
+
+	if *manifest == "" && len(dirs) == 0 {
+		if _, err := os.Stat(parcel.ManifestFileName); err == nil {
+			*manifest = parcel.ManifestFileName
+		} else {
+			dirs = fsFlags{"."}
+		}
+	}
+	if *manifest != "" {
+		p, err := parcel.NewParcelFromManifest(*manifest)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "parcel:", err)
+			os.Exit(1)
+		}
+		os.Exit(parcelcli.Run(p, flag.Args(), os.Stdout, os.Stderr))
+	}
+
+	p := parcel.NewParcel()
+	for _, dir := range dirs {
+		priority := 0
+		if i := strings.LastIndexByte(dir, ':'); i >= 0 {
+			n, err := strconv.Atoi(dir[i+1:])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "parcel: bad priority in -fs %s\n", dir)
+				os.Exit(2)
+			}
+			dir, priority = dir[:i], n
+		}
+		p.RegisterFS(os.DirFS(dir), priority)
+	}
+	os.Exit(parcelcli.Run(p, flag.Args(), os.Stdout, os.Stderr))
+}
@@ -0,0 +1,256 @@
+// Command parcelgen writes SaveParcel and LoadParcel methods for struct
+// types so that parcel can save and load them without reflection.
+//
+// Add a directive next to the types and run go generate:
+//
+//	//go:generate go run github.com/Bradbev/parcel/src/cmd/parcelgen -type=Weapon,Level
+//
+// Basic fields are written directly, any other field is passed to the
+// reflective codec.  Fields the codec cannot save, such as channels and
+// funcs, are reported when generating.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma separated list of struct types")
+	file := flag.String("file", os.Getenv("GOFILE"), "file declaring the types, defaults to $GOFILE")
+	output := flag.String("output", "", "output file, defaults to <file>_parcel.go")
+	flag.Parse()
+
+	if *typeNames == "" || *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output == "" {
+		base := strings.TrimSuffix(*file, ".go")
+		if strings.HasSuffix(base, "_test") {
+			*output = strings.TrimSuffix(base, "_test") + "_parcel_test.go"
+		} else {
+			*output = base + "_parcel.go"
+		}
+	}
+
+	src, err := os.ReadFile(*file)
+	if err != nil {
+		fail(err)
+	}
+	code, err := generate(filepath.Base(*file), src, strings.Split(*typeNames, ","))
+	if err != nil {
+		fail(err)
+	}
+	if err := os.WriteFile(*output, code, 0o644); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "parcelgen:", err)
+	os.Exit(1)
+}
+
+// generate returns the source of the methods for the named types in src.
+func generate(filename string, src []byte, typeNames []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	structs := map[string]*ast.TypeSpec{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok {
+			if _, ok := spec.Type.(*ast.StructType); ok {
+				structs[spec.Name.Name] = spec
+			}
+		}
+		return true
+	})
+
+	g := &generator{fset: fset}
+	fmt.Fprintf(&g.buf, "// Code generated by parcelgen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&g.buf, "package %s\n\n", f.Name.Name)
+	if f.Name.Name != "parcel" {
+		fmt.Fprintf(&g.buf, "import \"github.com/Bradbev/parcel/src/parcel\"\n")
+		g.pkg = "parcel."
+	}
+
+	var errs []error
+	for _, name := range typeNames {
+		name = strings.TrimSpace(name)
+		spec, ok := structs[name]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("%s: no struct type %s", filename, name))
+		case spec.TypeParams != nil:
+			errs = append(errs, fmt.Errorf("%s: generic type %s is not supported", filename, name))
+		default:
+			errs = append(errs, g.writeType(name, spec.Type.(*ast.StructType)))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return format.Source(g.buf.Bytes())
+}
+
+type generator struct {
+	fset *token.FileSet
+	buf  bytes.Buffer
+	pkg  string
+}
+
+// fieldKind says how a field is written, basic kinds use the matching
+// Encoder and Decoder methods and everything else uses Value.
+type fieldKind int
+
+const (
+	valueField fieldKind = iota
+	stringField
+	boolField
+	intField
+	uintField
+	float32Field
+	float64Field
+)
+
+type field struct {
+	name string
+	typ  string
+	kind fieldKind
+}
+
+func (g *generator) writeType(name string, st *ast.StructType) error {
+	var fields []field
+	var errs []error
+	for _, f := range st.Fields.List {
+		typ := g.exprString(f.Type)
+		if len(f.Names) == 0 {
+			errs = append(errs, g.errorf(f, "%s: embedded field %s is not supported", name, typ))
+			continue
+		}
+		for _, ident := range f.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			kind, err := classify(f.Type)
+			if err != nil {
+				errs = append(errs, g.errorf(f, "%s.%s: %v", name, ident.Name, err))
+				continue
+			}
+			fields = append(fields, field{name: ident.Name, typ: typ, kind: kind})
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	b := &g.buf
+	fmt.Fprintf(b, "\nfunc (x *%s) SaveParcel(e *%sEncoder) error {\n", name, g.pkg)
+	for _, f := range fields {
+		switch f.kind {
+		case stringField:
+			fmt.Fprintf(b, "e.String(%q, x.%s)\n", f.name, f.name)
+		case boolField:
+			fmt.Fprintf(b, "e.Bool(%q, x.%s)\n", f.name, f.name)
+		case intField:
+			fmt.Fprintf(b, "e.Int(%q, int64(x.%s))\n", f.name, f.name)
+		case uintField:
+			fmt.Fprintf(b, "e.Uint(%q, uint64(x.%s))\n", f.name, f.name)
+		case float32Field:
+			fmt.Fprintf(b, "e.Float32(%q, x.%s)\n", f.name, f.name)
+		case float64Field:
+			fmt.Fprintf(b, "e.Float64(%q, x.%s)\n", f.name, f.name)
+		default:
+			fmt.Fprintf(b, "if err := e.Value(%q, &x.%s); err != nil {\nreturn err\n}\n", f.name, f.name)
+		}
+	}
+	fmt.Fprintf(b, "return nil\n}\n")
+
+	fmt.Fprintf(b, "\nfunc (x *%s) LoadParcel(d *%sDecoder) error {\n", name, g.pkg)
+	fmt.Fprintf(b, "for d.Next() {\nswitch d.Name() {\n")
+	for _, f := range fields {
+		fmt.Fprintf(b, "case %q:\n", f.name)
+		switch f.kind {
+		case stringField:
+			fmt.Fprintf(b, "x.%s = d.String()\n", f.name)
+		case boolField:
+			fmt.Fprintf(b, "x.%s = d.Bool()\n", f.name)
+		case intField, uintField:
+			if method, ok := sizedMethods[f.typ]; ok {
+				fmt.Fprintf(b, "x.%s = d.%s()\n", f.name, method)
+			} else if f.kind == intField {
+				fmt.Fprintf(b, "x.%s = %s(d.Int())\n", f.name, f.typ)
+			} else {
+				fmt.Fprintf(b, "x.%s = %s(d.Uint())\n", f.name, f.typ)
+			}
+		case float32Field:
+			fmt.Fprintf(b, "x.%s = float32(d.Float64())\n", f.name)
+		case float64Field:
+			fmt.Fprintf(b, "x.%s = d.Float64()\n", f.name)
+		default:
+			fmt.Fprintf(b, "d.Value(&x.%s)\n", f.name)
+		}
+	}
+	fmt.Fprintf(b, "default:\nd.Unknown()\n}\n}\nreturn d.Err()\n}\n")
+	return nil
+}
+
+// sizedMethods are the Decoder methods that check an integer fits in
+// a narrow type.
+var sizedMethods = map[string]string{
+	"int8": "Int8", "int16": "Int16", "int32": "Int32", "rune": "Int32",
+	"uint8": "Uint8", "uint16": "Uint16", "uint32": "Uint32", "byte": "Uint8",
+}
+
+// classify works from the syntax alone, so named types such as
+// time.Duration fall back to Value even when their underlying type is
+// basic.  That is slower but always writes the same format.
+func classify(expr ast.Expr) (fieldKind, error) {
+	switch t := expr.(type) {
+	case *ast.ChanType:
+		return 0, errors.New("chan fields cannot be saved")
+	case *ast.FuncType:
+		return 0, errors.New("func fields cannot be saved")
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return stringField, nil
+		case "bool":
+			return boolField, nil
+		case "int", "int8", "int16", "int32", "int64", "rune":
+			return intField, nil
+		case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+			return uintField, nil
+		case "float32":
+			return float32Field, nil
+		case "float64":
+			return float64Field, nil
+		case "complex64", "complex128", "uintptr":
+			return 0, fmt.Errorf("%s fields cannot be saved", t.Name)
+		}
+	}
+	return valueField, nil
+}
+
+func (g *generator) exprString(expr ast.Expr) string {
+	var b bytes.Buffer
+	format.Node(&b, g.fset, expr)
+	return b.String()
+}
+
+func (g *generator) errorf(n ast.Node, msg string, args ...any) error {
+	return fmt.Errorf("%s: %s", g.fset.Position(n.Pos()), fmt.Sprintf(msg, args...))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const src = `package game
+
+type Kind int
+
+type Weapon struct {
+	Name   string
+	Damage int16
+	Kind   Kind
+	Scale  float64
+	Parts  []string
+	secret string
+}
+
+type Broken struct {
+	Updates chan int
+	OnHit   func()
+	Phase   complex64
+	Weapon
+}
+`
+
+func TestGenerate(t *testing.T) {
+	code, err := generate("game.go", []byte(src), []string{"Weapon"})
+	assert.NoError(t, err)
+	out := string(code)
+	assert.Contains(t, out, "package game")
+	assert.Contains(t, out, `import "github.com/Bradbev/parcel/src/parcel"`)
+	assert.Contains(t, out, "func (x *Weapon) SaveParcel(e *parcel.Encoder) error {")
+	assert.Contains(t, out, `e.Int("Damage", int64(x.Damage))`)
+	assert.Contains(t, out, `x.Damage = d.Int16()`, "narrow ints are range checked")
+	assert.Contains(t, out, `e.Value("Kind", &x.Kind)`, "named types use the reflective codec")
+	assert.Contains(t, out, `d.Value(&x.Parts)`)
+	assert.NotContains(t, out, "secret")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, err := generate("game.go", []byte(src), []string{"Broken", "Missing"})
+	assert.ErrorContains(t, err, "Broken.Updates: chan fields cannot be saved")
+	assert.ErrorContains(t, err, "Broken.OnHit: func fields cannot be saved")
+	assert.ErrorContains(t, err, "Broken.Phase: complex64 fields cannot be saved")
+	assert.ErrorContains(t, err, "embedded field Weapon is not supported")
+	assert.ErrorContains(t, err, "no struct type Missing")
+}
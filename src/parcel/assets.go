@@ -0,0 +1,40 @@
+package parcel
+
+import (
+	"io/fs"
+	"maps"
+	"slices"
+)
+
+// Assets returns the path of every asset in the registered filesystems,
+// sorted.  A path that is in more than one filesystem is listed once,
+// ReadFile and Load use the copy from the lowest priority filesystem.
+func (p *Parcel) Assets() ([]string, error) {
+	seen := map[string]bool{}
+	for _, f := range p.fsys {
+		err := fs.WalkDir(f.fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && (p.ext == "" || p.IsAssetPath(path)) {
+				seen[path] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return slices.Sorted(maps.Keys(seen)), nil
+}
+
+// TypeNames returns the names used in the Type header for every
+// registered type, sorted.
+func (p *Parcel) TypeNames() []string {
+	names := make([]string, 0, len(p.objectNewFunc))
+	for typ := range p.objectNewFunc {
+		names = append(names, typeStr(typ))
+	}
+	slices.Sort(names)
+	return names
+}
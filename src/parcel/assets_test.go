@@ -0,0 +1,28 @@
+package parcel_test
+
+import (
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssets(t *testing.T) {
+	p := parcel.NewParcel()
+	low := parcel.NewMemoryFS()
+	high := parcel.NewMemoryFS()
+	p.RegisterFS(low, 10)
+	p.RegisterFS(high, 0)
+	low.WriteFile("a.parcel", []byte("{}"))
+	low.WriteFile("dir/b.parcel", []byte("{}"))
+	low.WriteFile("notes.txt", []byte("x"))
+	high.WriteFile("a.parcel", []byte("{}"))
+	high.WriteFile("c.parcel", []byte("{}"))
+
+	assets, err := p.Assets()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.parcel", "c.parcel", "dir/b.parcel"}, assets)
+
+	p.AddType(&testType{})
+	assert.Equal(t, []string{"*parcel_test.testType"}, p.TypeNames())
+}
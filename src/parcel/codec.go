@@ -0,0 +1,167 @@
+package parcel
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// defaultExt is the extension of a new Parcel, saved with the JSON codec.
+const defaultExt = ".parcel"
+
+// Codec converts between the bytes stored in asset files and the
+// canonical JSON that the reflective codec reads and writes.  Codecs
+// are picked by the extension of the file, path is passed so that
+// codecs can keep per-file state or report it in errors.
+type Codec interface {
+	Encode(path string, canonical []byte) ([]byte, error)
+	Decode(path string, data []byte) ([]byte, error)
+}
+
+// PathStateCodec is implemented by codecs that keep state per path,
+// such as the comments kept by YAMLCodec.  The state is forgotten when
+// the asset is unloaded or deleted and follows it when it is moved.
+// State and SetState save and put back the state for one path, so that
+// a failed Txn can be undone; a nil state means there is none.
+type PathStateCodec interface {
+	Codec
+	Forget(path string)
+	Rename(from string, to string)
+	State(path string) any
+	SetState(path string, state any)
+}
+
+// jsonCodec stores the canonical JSON, laid out by the FormatOptions.
+type jsonCodec struct {
+	p *Parcel
+}
+
+func (c jsonCodec) Encode(path string, canonical []byte) ([]byte, error) {
+	return c.p.formatData(canonical)
+}
+
+func (c jsonCodec) Decode(path string, data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// SetExtension sets the extension added to paths that do not already
+// end in the extension of a registered codec.  Files with the extension
+// use the JSON codec unless another codec is registered for it.
+// An empty ext turns off extensions, paths are used exactly as given
+// and files that do not match a registered codec are JSON.
+func (p *Parcel) SetExtension(ext string) error {
+	if ext != "" && (!strings.HasPrefix(ext, ".") || strings.ContainsAny(ext, "/\\")) {
+		return fmt.Errorf("%w: extension %q must start with a dot", ErrInvalidType, ext)
+	}
+	p.ext = ext
+	if _, ok := p.codecs[ext]; !ok && ext != "" {
+		p.codecs[ext] = jsonCodec{p}
+	}
+	return nil
+}
+
+// Extension returns the extension added to asset paths, or "" if
+// extensions are turned off.
+func (p *Parcel) Extension() string {
+	return p.ext
+}
+
+// RegisterCodec makes files ending in ext load and save with c.
+func (p *Parcel) RegisterCodec(ext string, c Codec) error {
+	if !strings.HasPrefix(ext, ".") {
+		return fmt.Errorf("%w: extension %q must start with a dot", ErrInvalidType, ext)
+	}
+	p.codecs[ext] = c
+	return nil
+}
+
+// Extensions returns the extensions with a registered codec, sorted.
+func (p *Parcel) Extensions() []string {
+	return slices.Sorted(maps.Keys(p.codecs))
+}
+
+// IsAssetPath reports whether path ends in the extension of a
+// registered codec.
+func (p *Parcel) IsAssetPath(path string) bool {
+	_, ok := p.codecs[filepath.Ext(path)]
+	return ok
+}
+
+// AssetPath returns path with the extension added the same way Load
+// adds it.
+func (p *Parcel) AssetPath(path string) string {
+	return p.normPath(path)
+}
+
+// CodecFor returns the codec that reads and writes path.
+func (p *Parcel) CodecFor(path string) Codec {
+	if c, ok := p.codecs[filepath.Ext(path)]; ok {
+		return c
+	}
+	if c, ok := p.codecs[p.ext]; ok {
+		return c
+	}
+	return jsonCodec{p}
+}
+
+func (p *Parcel) normPath(path string) string {
+	if p.ext == "" || p.IsAssetPath(path) {
+		return path
+	}
+	return path + p.ext
+}
+
+// ReadAsset reads the asset at path and returns its canonical JSON.
+func (p *Parcel) ReadAsset(path string) ([]byte, error) {
+	data, err := p.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := p.CodecFor(path).Decode(path, data)
+	if err != nil {
+		return nil, &DecodeError{Path: path, Chain: slices.Clone(p.loadStack), Err: err}
+	}
+	return canonical, nil
+}
+
+func (p *Parcel) forgetCodecState(path string) {
+	if c, ok := p.CodecFor(path).(PathStateCodec); ok {
+		c.Forget(path)
+	}
+}
+
+func (p *Parcel) codecState(path string) any {
+	if c, ok := p.CodecFor(path).(PathStateCodec); ok {
+		return c.State(path)
+	}
+	return nil
+}
+
+func (p *Parcel) setCodecState(path string, state any) {
+	if c, ok := p.CodecFor(path).(PathStateCodec); ok {
+		c.SetState(path, state)
+	}
+}
+
+// renameCodecState moves the state for from to to, if both paths use the
+// same codec.
+func (p *Parcel) renameCodecState(from string, to string) {
+	c, ok := p.CodecFor(from).(PathStateCodec)
+	if ok && Codec(c) == p.CodecFor(to) {
+		c.Rename(from, to)
+	}
+}
+
+// convert re-encodes data stored at from so that it can be stored at to.
+func (p *Parcel) convert(from string, to string, data []byte) ([]byte, error) {
+	if filepath.Ext(from) == filepath.Ext(to) {
+		return data, nil
+	}
+	canonical, err := p.CodecFor(from).Decode(from, data)
+	if err != nil {
+		return nil, err
+	}
+	return p.CodecFor(to).Encode(to, canonical)
+}
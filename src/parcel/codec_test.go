@@ -0,0 +1,102 @@
+package parcel_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+// gzipCodec stores the canonical JSON compressed.
+type gzipCodec struct{}
+
+func (gzipCodec) Encode(path string, canonical []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(canonical)
+	err := w.Close()
+	return buf.Bytes(), err
+}
+
+func (gzipCodec) Decode(path string, data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestExtension(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	assert.Equal(t, ".parcel", p.Extension())
+	assert.NoError(t, p.SetExtension(".asset"))
+	assert.Error(t, p.SetExtension("asset"))
+
+	assert.NoError(t, p.SetSavePath(&testType{String: "a"}, "dir/my.level"))
+	_, err := store.ReadFile("dir/my.level.asset")
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.RegisterCodec(".json", p.CodecFor(".asset")))
+	assert.NoError(t, p.SetSavePath(&testType{String: "b"}, "data.json"))
+	_, err = store.ReadFile("data.json")
+	assert.NoError(t, err, "paths ending in a registered extension are left alone")
+	assert.Equal(t, []string{".asset", ".json", ".parcel"}, p.Extensions())
+}
+
+func TestNoExtension(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	assert.NoError(t, p.SetExtension(""))
+
+	obj := &testType{String: "plain"}
+	assert.NoError(t, p.SetSavePath(obj, "v1.2/thing"))
+	_, err := store.ReadFile("v1.2/thing")
+	assert.NoError(t, err)
+
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	p2.SetExtension("")
+	p2.AddType(&testType{})
+	loaded, err := p2.Load(&testType{}, "v1.2/thing")
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", loaded.(*testType).String)
+	assets, _ := p2.Assets()
+	assert.Equal(t, []string{"v1.2/thing"}, assets)
+}
+
+func TestCodecByExtension(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	assert.NoError(t, p.RegisterCodec(".parcelz", gzipCodec{}))
+
+	obj := &testType{String: "zipped"}
+	assert.NoError(t, p.SetSavePath(obj, "small.parcelz"))
+	data, _ := store.ReadFile("small.parcelz")
+	assert.Equal(t, []byte{0x1f, 0x8b}, data[:2])
+
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	p2.RegisterCodec(".parcelz", gzipCodec{})
+	p2.AddType(&testType{})
+	loaded, err := p2.Load(&testType{}, "small.parcelz")
+	assert.NoError(t, err)
+	assert.Equal(t, "zipped", loaded.(*testType).String)
+
+	// moving an unloaded asset converts it to the codec of the new path
+	p3 := parcel.NewParcel()
+	setupBasic(p3, setupOpts{Store: store})
+	p3.RegisterCodec(".parcelz", gzipCodec{})
+	assert.NoError(t, p3.Move("small.parcelz", "big"))
+	data, _ = store.ReadFile("big.parcel")
+	assert.Contains(t, string(data), `"String":"zipped"`)
+
+	_, err = p3.ReadAsset("big")
+	assert.Error(t, err, "ReadAsset takes a full path")
+	canonical, err := p3.ReadAsset("big.parcel")
+	assert.NoError(t, err)
+	assert.Equal(t, data, canonical)
+}
@@ -0,0 +1,223 @@
+package parcel
+
+/*
+Types can skip the reflective codec by implementing ParcelSaver and
+ParcelLoader, usually with methods written by cmd/parcelgen.  The
+generated methods write the same format as the reflective codec, so
+assets can be moved between the two freely.  The methods are only
+called for struct values, CustomSaveLoader takes priority.
+*/
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/launchdarkly/go-jsonstream/v3/jreader"
+	"github.com/launchdarkly/go-jsonstream/v3/jwriter"
+)
+
+// ParcelSaver is implemented by struct types with generated codecs.
+type ParcelSaver interface {
+	SaveParcel(e *Encoder) error
+}
+
+// ParcelLoader is the load counterpart of ParcelSaver.
+type ParcelLoader interface {
+	LoadParcel(d *Decoder) error
+}
+
+var (
+	parcelSaver  = reflect.TypeFor[ParcelSaver]()
+	parcelLoader = reflect.TypeFor[ParcelLoader]()
+)
+
+// Encoder writes the fields of a struct, in the order they are called.
+type Encoder struct {
+	p   *Parcel
+	obj jwriter.ObjectState
+}
+
+func (e *Encoder) String(name string, v string) {
+	e.obj.Name(name).String(v)
+}
+
+func (e *Encoder) Bool(name string, v bool) {
+	e.obj.Name(name).Bool(v)
+}
+
+func (e *Encoder) Int(name string, v int64) {
+	writeInt(e.obj.Name(name), v)
+}
+
+func (e *Encoder) Uint(name string, v uint64) {
+	writeUint(e.obj.Name(name), v)
+}
+
+func (e *Encoder) Float32(name string, v float32) {
+	// write the shortest form that round trips as a float32
+	f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+	e.obj.Name(name).Float64(f)
+}
+
+func (e *Encoder) Float64(name string, v float64) {
+	e.obj.Name(name).Float64(v)
+}
+
+// Value writes any other field with the reflective codec.  ptr must be
+// a pointer to the field.
+func (e *Encoder) Value(name string, ptr any) error {
+	return e.p.saveField(&e.obj, name, reflect.ValueOf(ptr).Elem())
+}
+
+// Decoder reads the fields of a struct.  Call Next until it returns
+// false, then return Err.
+type Decoder struct {
+	p   *Parcel
+	pr  *preader
+	obj jreader.ObjectState
+	err error
+	// names of the fields read, kept when the type has field rules
+	seen map[string]bool
+}
+
+func (d *Decoder) Next() bool {
+	if d.err != nil || !d.obj.Next() {
+		return false
+	}
+	if d.seen != nil {
+		d.seen[string(d.obj.Name())] = true
+	}
+	return true
+}
+
+// Name is the name of the current field.
+func (d *Decoder) Name() string {
+	return string(d.obj.Name())
+}
+
+func (d *Decoder) String() string {
+	return d.pr.r.String()
+}
+
+func (d *Decoder) Bool() bool {
+	return d.pr.r.Bool()
+}
+
+func (d *Decoder) Int() int64 {
+	n, err := readInt(d.pr.r)
+	d.fail(err)
+	return n
+}
+
+func (d *Decoder) Uint() uint64 {
+	n, err := readUint(d.pr.r)
+	d.fail(err)
+	return n
+}
+
+// Int8, Int16 and Int32 read an Int and fail if it does not fit.
+func (d *Decoder) Int8() int8 {
+	return int8(d.intBits(8))
+}
+
+func (d *Decoder) Int16() int16 {
+	return int16(d.intBits(16))
+}
+
+func (d *Decoder) Int32() int32 {
+	return int32(d.intBits(32))
+}
+
+// Uint8, Uint16 and Uint32 read a Uint and fail if it does not fit.
+func (d *Decoder) Uint8() uint8 {
+	return uint8(d.uintBits(8))
+}
+
+func (d *Decoder) Uint16() uint16 {
+	return uint16(d.uintBits(16))
+}
+
+func (d *Decoder) Uint32() uint32 {
+	return uint32(d.uintBits(32))
+}
+
+func (d *Decoder) intBits(bits int) int64 {
+	n := d.Int()
+	if limit := int64(1) << (bits - 1); n < -limit || n >= limit {
+		d.fail(fmt.Errorf("%w: %d does not fit in int%d", ErrInvalidType, n, bits))
+		return 0
+	}
+	return n
+}
+
+func (d *Decoder) uintBits(bits int) uint64 {
+	n := d.Uint()
+	if n >= uint64(1)<<bits {
+		d.fail(fmt.Errorf("%w: %d does not fit in uint%d", ErrInvalidType, n, bits))
+		return 0
+	}
+	return n
+}
+
+func (d *Decoder) Float64() float64 {
+	return d.pr.r.Float64()
+}
+
+// Value reads any other field with the reflective codec.  ptr must be
+// a pointer to the field.
+func (d *Decoder) Value(ptr any) {
+	d.fail(d.p.loadField(d.pr, reflect.ValueOf(ptr).Elem()))
+}
+
+// Unknown records that the current field is not in the struct, so that
+// it is kept when the asset is saved.
+func (d *Decoder) Unknown() {
+	d.pr.unknown = true
+}
+
+// Err returns the first error seen while decoding.
+func (d *Decoder) Err() error {
+	if d.err != nil {
+		return d.err
+	}
+	return d.pr.r.Error()
+}
+
+func (d *Decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func compileGeneratedEncoder(typ reflect.Type) encodeFunc {
+	return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+		if !v.CanAddr() {
+			cp := reflect.New(typ).Elem()
+			cp.Set(v)
+			v = cp
+		}
+		e := &Encoder{p: p, obj: w.Object()}
+		err := v.Addr().Interface().(ParcelSaver).SaveParcel(e)
+		e.obj.End()
+		return err
+	}
+}
+
+func compileGeneratedDecoder(typ reflect.Type) decodeFunc {
+	plan := planFor(typ)
+	return func(p *Parcel, pr *preader, v reflect.Value) error {
+		d := &Decoder{p: p, pr: pr, obj: pr.object()}
+		if plan.hasRules {
+			d.seen = map[string]bool{}
+		}
+		if err := v.Addr().Interface().(ParcelLoader).LoadParcel(d); err != nil || d.seen == nil {
+			return err
+		}
+		seen := make([]bool, len(plan.fields))
+		for i, field := range plan.fields {
+			seen[i] = d.seen[field.name]
+		}
+		return p.applyFieldRules(plan, v, seen)
+	}
+}
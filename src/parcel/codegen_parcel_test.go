@@ -0,0 +1,106 @@
+// Code generated by parcelgen; DO NOT EDIT.
+
+package parcel_test
+
+import "github.com/Bradbev/parcel/src/parcel"
+
+func (x *genWeapon) SaveParcel(e *parcel.Encoder) error {
+	e.String("Name", x.Name)
+	e.Int("Damage", int64(x.Damage))
+	e.Float32("Weight", x.Weight)
+	e.Bool("Rare", x.Rare)
+	e.Uint("Big", uint64(x.Big))
+	if err := e.Value("Tags", &x.Tags); err != nil {
+		return err
+	}
+	if err := e.Value("Owner", &x.Owner); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (x *genWeapon) LoadParcel(d *parcel.Decoder) error {
+	for d.Next() {
+		switch d.Name() {
+		case "Name":
+			x.Name = d.String()
+		case "Damage":
+			x.Damage = d.Int32()
+		case "Weight":
+			x.Weight = float32(d.Float64())
+		case "Rare":
+			x.Rare = d.Bool()
+		case "Big":
+			x.Big = uint64(d.Uint())
+		case "Tags":
+			d.Value(&x.Tags)
+		case "Owner":
+			d.Value(&x.Owner)
+		default:
+			d.Unknown()
+		}
+	}
+	return d.Err()
+}
+
+func (x *genLevel) SaveParcel(e *parcel.Encoder) error {
+	e.String("Name", x.Name)
+	if err := e.Value("Weapons", &x.Weapons); err != nil {
+		return err
+	}
+	if err := e.Value("Boss", &x.Boss); err != nil {
+		return err
+	}
+	if err := e.Value("Counts", &x.Counts); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (x *genLevel) LoadParcel(d *parcel.Decoder) error {
+	for d.Next() {
+		switch d.Name() {
+		case "Name":
+			x.Name = d.String()
+		case "Weapons":
+			d.Value(&x.Weapons)
+		case "Boss":
+			d.Value(&x.Boss)
+		case "Counts":
+			d.Value(&x.Counts)
+		default:
+			d.Unknown()
+		}
+	}
+	return d.Err()
+}
+
+func (x *genRules) SaveParcel(e *parcel.Encoder) error {
+	e.String("Name", x.Name)
+	e.Int("Health", int64(x.Health))
+	if err := e.Value("Tags", &x.Tags); err != nil {
+		return err
+	}
+	if err := e.Value("Stats", &x.Stats); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (x *genRules) LoadParcel(d *parcel.Decoder) error {
+	for d.Next() {
+		switch d.Name() {
+		case "Name":
+			x.Name = d.String()
+		case "Health":
+			x.Health = int(d.Int())
+		case "Tags":
+			d.Value(&x.Tags)
+		case "Stats":
+			d.Value(&x.Stats)
+		default:
+			d.Unknown()
+		}
+	}
+	return d.Err()
+}
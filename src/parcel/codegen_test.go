@@ -0,0 +1,113 @@
+package parcel_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+//go:generate go run ../cmd/parcelgen -type=genWeapon,genLevel,genRules
+
+type genWeapon struct {
+	Name   string
+	Damage int32
+	Weight float32
+	Rare   bool
+	Big    uint64
+	Tags   []string
+	Owner  *testType
+	hidden int
+}
+
+type genLevel struct {
+	Name    string
+	Weapons []genWeapon
+	Boss    *genWeapon
+	Counts  map[string]uint16
+}
+
+// genRules has the same fields as rulesAsset in fieldrules_test.go.
+type genRules struct {
+	Name   string   `parcel:"required"`
+	Health int      `parcel:"default=100"`
+	Tags   []string `parcel:"default='[\"new\"]'"`
+	Stats  rulesStats
+}
+
+// reflectLevel has the same fields as genLevel but no generated methods.
+type reflectLevel struct {
+	Name    string
+	Weapons []reflectWeapon
+	Boss    *reflectWeapon
+	Counts  map[string]uint16
+}
+
+type reflectWeapon struct {
+	Name   string
+	Damage int32
+	Weight float32
+	Rare   bool
+	Big    uint64
+	Tags   []string
+	Owner  *testType
+	hidden int
+}
+
+func TestGeneratedCodecs(t *testing.T) {
+	var _ parcel.ParcelSaver = &genLevel{}
+	var _ parcel.ParcelLoader = &genLevel{}
+
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&genLevel{})
+	p.AddType(&reflectLevel{})
+	owner := &testType{String: "owner"}
+	p.SetSavePath(owner, "owner")
+
+	gen := &genLevel{
+		Name:    "level",
+		Weapons: []genWeapon{{Name: "sword", Damage: -3, Weight: 0.1, Rare: true, Big: 1 << 60, Tags: []string{"a"}, Owner: owner}},
+		Boss:    &genWeapon{Name: "boss"},
+		Counts:  map[string]uint16{"a": 1},
+	}
+	refl := &reflectLevel{
+		Name:    "level",
+		Weapons: []reflectWeapon{{Name: "sword", Damage: -3, Weight: 0.1, Rare: true, Big: 1 << 60, Tags: []string{"a"}, Owner: owner}},
+		Boss:    &reflectWeapon{Name: "boss"},
+		Counts:  map[string]uint16{"a": 1},
+	}
+	assert.NoError(t, p.SetSavePath(gen, "gen"))
+	assert.NoError(t, p.SetSavePath(refl, "refl"))
+	genData, _ := store.ReadFile("gen.parcel")
+	reflData, _ := store.ReadFile("refl.parcel")
+	assert.Equal(t, strings.Replace(string(reflData), "reflectLevel", "genLevel", 1), string(genData),
+		"generated and reflective codecs write the same format")
+
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	p2.AddType(&genLevel{})
+	loaded, err := p2.Load(&genLevel{}, "gen")
+	assert.NoError(t, err)
+	back := loaded.(*genLevel)
+	assert.Equal(t, gen.Weapons[0].Big, back.Weapons[0].Big)
+	assert.Equal(t, "owner", back.Weapons[0].Owner.String)
+	back.Weapons[0].Owner = owner
+	assert.Equal(t, gen, back)
+}
+
+func TestGeneratedCodecsCheckRange(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&genWeapon{})
+	store.WriteFile("big.parcel", []byte(`{"Type":"*parcel_test.genWeapon","Parent":"","Obj":{"Damage":3000000000}}`))
+	_, err := p.Load(&genWeapon{}, "big")
+	assert.ErrorIs(t, err, parcel.ErrInvalidType)
+	assert.ErrorContains(t, err, "3000000000 does not fit in int32")
+
+	store.WriteFile("small.parcel", []byte(`{"Type":"*parcel_test.genWeapon","Parent":"","Obj":{"Damage":-2147483648}}`))
+	loaded, err := p.Load(&genWeapon{}, "small")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(-2147483648), loaded.(*genWeapon).Damage)
+}
@@ -0,0 +1,250 @@
+package parcel
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PropertyKind says how a property is saved.
+type PropertyKind int
+
+const (
+	// ValueProperty is a basic value, a byte slice or a value saved by
+	// a CustomSaveLoader.
+	ValueProperty PropertyKind = iota
+	// ObjectProperty is a struct saved in place.
+	ObjectProperty
+	// ReferenceProperty is a pointer or interface field that holds
+	// another asset, saved as its path.  Nil fields that could hold an
+	// asset are references with an empty Ref.
+	ReferenceProperty
+	ListProperty
+	MapProperty
+)
+
+func (k PropertyKind) String() string {
+	switch k {
+	case ValueProperty:
+		return "value"
+	case ObjectProperty:
+		return "object"
+	case ReferenceProperty:
+		return "reference"
+	case ListProperty:
+		return "list"
+	case MapProperty:
+		return "map"
+	}
+	return fmt.Sprintf("PropertyKind(%d)", int(k))
+}
+
+// Property describes one value in an object, for building editors.  The
+// tree holds exactly the values that Save writes, in the same order.
+type Property struct {
+	// Name is the field name, slice index or map key.
+	Name string
+	// Path names the value for SetProperty, such as "Weapons[2].Damage".
+	Path  string
+	Type  reflect.Type
+	Kind  PropertyKind
+	Value any
+	// Ref is the path of the asset a ReferenceProperty holds.
+	Ref string
+	// Tags holds the options from the field's parcel struct tag, such
+	// as `parcel:"min=0,max=100,tooltip=Damage per hit"`.  Options
+	// without a value, such as required, map to "".
+	Tags     map[string]string
+	Children []*Property
+}
+
+// Min returns the min tag.
+func (prop *Property) Min() (float64, bool) {
+	return prop.floatTag("min")
+}
+
+// Max returns the max tag.
+func (prop *Property) Max() (float64, bool) {
+	return prop.floatTag("max")
+}
+
+// Tooltip returns the tooltip tag.
+func (prop *Property) Tooltip() string {
+	return prop.Tags["tooltip"]
+}
+
+// Category returns the category tag.
+func (prop *Property) Category() string {
+	return prop.Tags["category"]
+}
+
+func (prop *Property) floatTag(name string) (float64, bool) {
+	s, ok := prop.Tags[name]
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+// Child returns the child with the given name, or nil.
+func (prop *Property) Child(name string) *Property {
+	for _, c := range prop.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// parseTag splits a parcel struct tag into its options.  Options are
+// separated by commas, a value may be single quoted to hold commas.
+func parseTag(tag string) map[string]string {
+	if tag == "" {
+		return nil
+	}
+	opts := map[string]string{}
+	for tag != "" {
+		var opt string
+		key, rest, hasValue := strings.Cut(tag, "=")
+		if comma := strings.IndexByte(key, ','); comma >= 0 || !hasValue {
+			// an option without a value
+			if comma < 0 {
+				comma = len(key)
+			}
+			opt, tag = key[:comma], strings.TrimPrefix(tag[comma:], ",")
+			if opt = strings.TrimSpace(opt); opt != "" {
+				opts[opt] = ""
+			}
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if strings.HasPrefix(rest, "'") {
+			if end := strings.IndexByte(rest[1:], '\''); end >= 0 {
+				opts[key] = rest[1 : end+1]
+				tag = strings.TrimPrefix(rest[end+2:], ",")
+				continue
+			}
+		}
+		opt, tag, _ = strings.Cut(rest, ",")
+		opts[key] = opt
+	}
+	return opts
+}
+
+// Describe returns the properties of obj, which must be a non nil
+// pointer.  References to other assets are not expanded.
+func (p *Parcel) Describe(obj any) (*Property, error) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, fmt.Errorf("%w: Describe needs a non nil pointer, not %T", ErrInvalidType, obj)
+	}
+	root := &Property{Type: v.Type(), Value: obj}
+	p.describe(root, v.Elem())
+	return root, nil
+}
+
+// describe fills in the kind and children of prop from v.
+func (p *Parcel) describe(prop *Property, v reflect.Value) {
+	for (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && !v.IsNil() && !planFor(v.Type()).custom {
+		v = v.Elem()
+	}
+	if planFor(v.Type()).custom || v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		prop.Kind = ValueProperty
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		prop.Kind = ObjectProperty
+		for _, f := range planFor(v.Type()).fields {
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil {
+				continue // nil embedded pointer
+			}
+			child := &Property{
+				Name: f.name,
+				Path: childPath(prop.Path, v.Type(), f.name),
+				Type: f.typ,
+				Tags: f.tags,
+			}
+			if fv.CanInterface() {
+				child.Value = fv.Interface()
+			}
+			prop.Children = append(prop.Children, child)
+			if p.describeReference(child, fv) {
+				continue
+			}
+			p.describe(child, fv)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem() == reflect.TypeFor[byte]() {
+			prop.Kind = ValueProperty
+			return
+		}
+		prop.Kind = ListProperty
+		for i := range v.Len() {
+			ev := v.Index(i)
+			child := &Property{Name: strconv.Itoa(i), Path: indexPath(prop.Path, i), Type: ev.Type(), Value: ev.Interface()}
+			prop.Children = append(prop.Children, child)
+			p.describe(child, ev)
+		}
+	case reflect.Map:
+		prop.Kind = MapProperty
+		type entry struct {
+			name  string
+			value reflect.Value
+		}
+		var entries []entry
+		itr := v.MapRange()
+		for itr.Next() {
+			name, err := p.resolveKeyName(itr.Key())
+			if err != nil {
+				name = fmt.Sprint(itr.Key())
+			}
+			entries = append(entries, entry{name, itr.Value()})
+		}
+		slices.SortFunc(entries, func(a, b entry) int { return strings.Compare(a.name, b.name) })
+		for _, e := range entries {
+			child := &Property{Name: e.name, Path: childPath(prop.Path, v.Type(), e.name), Type: e.value.Type(), Value: e.value.Interface()}
+			prop.Children = append(prop.Children, child)
+			p.describe(child, e.value)
+		}
+	default:
+		prop.Kind = ValueProperty
+	}
+}
+
+// describeReference fills in prop if the struct field fv is saved as a
+// reference, the same way saveField decides.
+func (p *Parcel) describeReference(prop *Property, fv reflect.Value) bool {
+	kind := fv.Kind()
+	if kind != reflect.Pointer && kind != reflect.Interface {
+		return false
+	}
+	if fv.IsNil() {
+		if kind == reflect.Interface || isStruct(fv.Type().Elem()) {
+			prop.Kind = ReferenceProperty
+			return true
+		}
+		return false
+	}
+	if path, ok := p.pathOf(fv.Interface()); ok {
+		prop.Kind = ReferenceProperty
+		prop.Ref = path
+		return true
+	}
+	return false
+}
+
+// SetProperty sets the value at path in obj, such as "Weapons[2].Damage".
+// Numbers are converted to the type of the field if they fit, and nil
+// sets the zero value.  A json.RawMessage is decoded the way Load
+// decodes the field, so byte slices are base64.  A string set on a
+// pointer or interface field is the path of the asset to refer to, and
+// one set on a number or bool field is parsed, so "42" sets an int.
+// Missing map keys and nil pointers along the path are created.
+func (p *Parcel) SetProperty(obj any, path string, value any) error {
+	return p.setPath(obj, path, value)
+}
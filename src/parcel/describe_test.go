@@ -0,0 +1,106 @@
+package parcel_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+type inspectedStats struct {
+	Damage int `parcel:"min=0,max=100,tooltip='Damage, per hit',category=Combat"`
+	Speed  float32
+}
+
+type inspectedAsset struct {
+	Name   string `parcel:"required"`
+	Stats  inspectedStats
+	Owner  *testType
+	Inline *inspectedStats
+	Empty  *testType
+	Tags   []string
+	Drops  map[string]inspectedStats
+	Data   []byte
+	secret int
+}
+
+func TestDescribe(t *testing.T) {
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{})
+	owner := &testType{String: "owner"}
+	assert.NoError(t, p.SetSavePath(owner, "owner"))
+	obj := &inspectedAsset{
+		Name:   "axe",
+		Stats:  inspectedStats{Damage: 10},
+		Owner:  owner,
+		Inline: &inspectedStats{Speed: 2},
+		Tags:   []string{"a", "b"},
+		Drops:  map[string]inspectedStats{"gold": {Damage: 1}},
+	}
+
+	root, err := p.Describe(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, parcel.ObjectProperty, root.Kind)
+	var names []string
+	for _, c := range root.Children {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"Name", "Stats", "Owner", "Inline", "Empty", "Tags", "Drops", "Data"}, names)
+
+	assert.Equal(t, map[string]string{"required": ""}, root.Child("Name").Tags)
+	damage := root.Child("Stats").Child("Damage")
+	assert.Equal(t, "Stats.Damage", damage.Path)
+	assert.Equal(t, 10, damage.Value)
+	min, _ := damage.Min()
+	max, _ := damage.Max()
+	assert.Equal(t, []float64{0, 100}, []float64{min, max})
+	assert.Equal(t, "Damage, per hit", damage.Tooltip())
+	assert.Equal(t, "Combat", damage.Category())
+
+	assert.Equal(t, parcel.ReferenceProperty, root.Child("Owner").Kind)
+	assert.Equal(t, "owner.parcel", root.Child("Owner").Ref)
+	assert.Empty(t, root.Child("Owner").Children)
+	assert.Equal(t, parcel.ObjectProperty, root.Child("Inline").Kind)
+	assert.Equal(t, "Inline.Speed", root.Child("Inline").Child("Speed").Path)
+	assert.Equal(t, parcel.ReferenceProperty, root.Child("Empty").Kind)
+	assert.Equal(t, "", root.Child("Empty").Ref)
+	assert.Equal(t, parcel.ListProperty, root.Child("Tags").Kind)
+	assert.Equal(t, "Tags[1]", root.Child("Tags").Child("1").Path)
+	assert.Equal(t, parcel.MapProperty, root.Child("Drops").Kind)
+	assert.Equal(t, "Drops[gold].Damage", root.Child("Drops").Child("gold").Child("Damage").Path)
+	assert.Equal(t, parcel.ValueProperty, root.Child("Data").Kind)
+
+	_, err = p.Describe(inspectedAsset{})
+	assert.ErrorIs(t, err, parcel.ErrInvalidType)
+}
+
+func TestSetProperty(t *testing.T) {
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{})
+	obj := &inspectedAsset{Tags: []string{"a"}}
+
+	assert.NoError(t, p.SetProperty(obj, "Stats.Damage", 42.0))
+	assert.Equal(t, 42, obj.Stats.Damage)
+	assert.NoError(t, p.SetProperty(obj, "Inline.Speed", 1))
+	assert.Equal(t, float32(1), obj.Inline.Speed)
+	assert.NoError(t, p.SetProperty(obj, "Drops[gold].Damage", int8(3)))
+	assert.Equal(t, 3, obj.Drops["gold"].Damage)
+	assert.NoError(t, p.SetProperty(obj, "Tags[1]", "b"))
+	assert.Equal(t, []string{"a", "b"}, obj.Tags)
+	assert.NoError(t, p.SetProperty(obj, "Tags", nil))
+	assert.Nil(t, obj.Tags)
+	assert.NoError(t, p.SetProperty(obj, "Data", json.RawMessage(`"AAEC"`)))
+	assert.Equal(t, []byte{0, 1, 2}, obj.Data)
+	owner := &testType{}
+	assert.NoError(t, p.SetSavePath(owner, "owner"))
+	assert.NoError(t, p.SetProperty(obj, "Owner", "owner"), "strings on references are paths")
+	assert.Same(t, owner, obj.Owner)
+
+	assert.ErrorIs(t, p.SetProperty(obj, "Stats.Damage", 1.5), parcel.ErrInvalidType)
+	assert.ErrorIs(t, p.SetProperty(obj, "Name", 1), parcel.ErrInvalidType)
+	assert.ErrorIs(t, p.SetProperty(obj, "secret", 1), parcel.ErrFieldPath)
+	assert.ErrorIs(t, p.SetProperty(obj, "Tags[5]", "x"), parcel.ErrFieldPath)
+	assert.ErrorIs(t, p.SetProperty(obj, "Stats..Damage", 1), parcel.ErrFieldPath)
+	assert.ErrorIs(t, p.SetProperty(obj, "Stats[0]", 1), parcel.ErrFieldPath)
+}
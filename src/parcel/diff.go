@@ -0,0 +1,315 @@
+package parcel
+
+/*
+Diff and Merge work on the serialized form of assets, so references are
+compared as their paths and maps are compared key by key, exactly as
+they are written by jsonSaveWriter.  When the Go type of an asset is
+known it is used to give map entries a [key] path rather than a .field
+path.
+*/
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota
+	Removed
+	Modified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change is a single difference between two assets.  Path is a field
+// path such as "Stats.Weapons[2].Damage".  Old and New hold the
+// serialized values, objects are map[string]any, arrays []any and
+// numbers json.Number.  References are their path strings.
+type Change struct {
+	Path string
+	Kind ChangeKind
+	Old  any
+	New  any
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s: %v", c.Path, c.New)
+	case Removed:
+		return fmt.Sprintf("- %s: %v", c.Path, c.Old)
+	}
+	return fmt.Sprintf("~ %s: %v -> %v", c.Path, c.Old, c.New)
+}
+
+// Conflict is a path that was changed differently by both sides of a
+// Merge.  The merged result keeps the Ours value.
+type Conflict struct {
+	Path   string
+	Base   any
+	Ours   any
+	Theirs any
+}
+
+// Diff returns the changes needed to turn object a into object b.
+func (p *Parcel) Diff(a any, b any) ([]Change, error) {
+	an, err := p.objectNode(a)
+	if err != nil {
+		return nil, err
+	}
+	bn, err := p.objectNode(b)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	diffNodes(&changes, "", reflect.TypeOf(a), an, bn)
+	return changes, nil
+}
+
+// DiffData returns the changes needed to turn the saved asset a into
+// the saved asset b.  Paths include the Type, Parent and Obj header.
+func (p *Parcel) DiffData(a []byte, b []byte) ([]Change, error) {
+	an, err := parseNode(a)
+	if err != nil {
+		return nil, err
+	}
+	bn, err := parseNode(b)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	diffNodes(&changes, "", p.fileType(an), an, bn)
+	return changes, nil
+}
+
+// Merge performs a three way merge of ours and theirs, which were both
+// derived from base.  The merged result is a new object of the same
+// type as ours.  Conflicting changes keep the value from ours and are
+// reported in the returned conflicts.
+func (p *Parcel) Merge(base any, ours any, theirs any) (any, []Conflict, error) {
+	var nodes [3]*node
+	for i, obj := range []any{base, ours, theirs} {
+		n, err := p.objectNode(obj)
+		if err != nil {
+			return nil, nil, err
+		}
+		nodes[i] = n
+	}
+	typ := reflect.TypeOf(ours)
+	var conflicts []Conflict
+	merged := mergeNodes(&conflicts, "", typ, nodes[0], nodes[1], nodes[2])
+
+	result, err := p.newFromType(typ)
+	if err != nil {
+		result = reflect.New(typ.Elem()).Interface()
+	}
+	if err := p.jsonLoad(result, merged.bytes()); err != nil {
+		return nil, nil, err
+	}
+	return result, conflicts, nil
+}
+
+// MergeData performs a three way merge of saved assets, for example
+// as a version control merge driver.
+func (p *Parcel) MergeData(base []byte, ours []byte, theirs []byte) ([]byte, []Conflict, error) {
+	var nodes [3]*node
+	for i, data := range [][]byte{base, ours, theirs} {
+		n, err := parseNode(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		nodes[i] = n
+	}
+	var conflicts []Conflict
+	merged := mergeNodes(&conflicts, "", p.fileType(nodes[1]), nodes[0], nodes[1], nodes[2])
+	data, err := p.formatData(merged.bytes())
+	return data, conflicts, err
+}
+
+func (p *Parcel) objectNode(obj any) (*node, error) {
+	data, err := p.jsonSave(obj)
+	if err != nil {
+		return nil, err
+	}
+	return parseNode(data)
+}
+
+// fileType returns the Go type of a whole saved file, if the Type
+// header names a registered type.
+func (p *Parcel) fileType(n *node) reflect.Type {
+	name, _ := n.field("Type").toAny().(string)
+	ptyp, ok := p.typeByName(name)
+	if !ok {
+		return nil
+	}
+	typ, err := p.getLoadableSaveFormatType(ptyp)
+	if err != nil {
+		return nil
+	}
+	return typ
+}
+
+func diffNodes(changes *[]Change, path string, typ reflect.Type, a *node, b *node) {
+	switch {
+	case a.equal(b):
+		return
+	case a == nil:
+		*changes = append(*changes, Change{Path: path, Kind: Added, New: b.toAny()})
+		return
+	case b == nil:
+		*changes = append(*changes, Change{Path: path, Kind: Removed, Old: a.toAny()})
+		return
+	}
+
+	typ = serializedType(typ)
+	if a.kind == objectNode && b.kind == objectNode {
+		for _, k := range unionKeys(typ, a, b) {
+			diffNodes(changes, childPath(path, typ, k), childType(typ, k), a.field(k), b.field(k))
+		}
+		return
+	}
+	if a.kind == arrayNode && b.kind == arrayNode {
+		for i := range max(len(a.elems), len(b.elems)) {
+			diffNodes(changes, indexPath(path, i), elemType(typ), a.elem(i), b.elem(i))
+		}
+		return
+	}
+	*changes = append(*changes, Change{Path: path, Kind: Modified, Old: a.toAny(), New: b.toAny()})
+}
+
+func mergeNodes(conflicts *[]Conflict, path string, typ reflect.Type, base *node, ours *node, theirs *node) *node {
+	switch {
+	case ours.equal(theirs):
+		return ours
+	case base.equal(ours):
+		return theirs
+	case base.equal(theirs):
+		return ours
+	}
+
+	typ = serializedType(typ)
+	if ours != nil && theirs != nil {
+		if ours.kind == objectNode && theirs.kind == objectNode && (base == nil || base.kind == objectNode) {
+			merged := &node{kind: objectNode, fields: map[string]*node{}}
+			for _, k := range unionKeys(typ, ours, theirs) {
+				m := mergeNodes(conflicts, childPath(path, typ, k), childType(typ, k),
+					base.field(k), ours.field(k), theirs.field(k))
+				if m != nil {
+					merged.keys = append(merged.keys, k)
+					merged.fields[k] = m
+				}
+			}
+			return merged
+		}
+		if ours.kind == arrayNode && theirs.kind == arrayNode && len(ours.elems) == len(theirs.elems) &&
+			(base == nil || (base.kind == arrayNode && len(base.elems) == len(ours.elems))) {
+			merged := &node{kind: arrayNode}
+			for i := range ours.elems {
+				m := mergeNodes(conflicts, indexPath(path, i), elemType(typ),
+					base.elem(i), ours.elem(i), theirs.elem(i))
+				merged.elems = append(merged.elems, m)
+			}
+			return merged
+		}
+	}
+	*conflicts = append(*conflicts, Conflict{
+		Path:   path,
+		Base:   base.toAny(),
+		Ours:   ours.toAny(),
+		Theirs: theirs.toAny(),
+	})
+	return ours
+}
+
+// unionKeys returns the keys of a followed by keys only in b.  Struct
+// fields are put in declaration order and map keys are sorted so that
+// the results do not depend on the order values were written in.
+func unionKeys(typ reflect.Type, a *node, b *node) []string {
+	keys := append([]string{}, a.keys...)
+	for _, k := range b.keys {
+		if _, ok := a.fields[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	switch {
+	case typ == nil:
+	case typ.Kind() == reflect.Map:
+		slices.Sort(keys)
+	case typ.Kind() == reflect.Struct:
+		slices.SortStableFunc(keys, func(a, b string) int {
+			return cmp.Compare(fieldOrder(typ, a), fieldOrder(typ, b))
+		})
+	}
+	return keys
+}
+
+func fieldOrder(typ reflect.Type, name string) int {
+	if f, ok := typ.FieldByName(name); ok {
+		return f.Index[0]
+	}
+	return typ.NumField()
+}
+
+// serializedType strips pointers from typ and returns nil if values of
+// typ are not saved in a shape that follows the type.
+func serializedType(typ reflect.Type) reflect.Type {
+	for typ != nil && (typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Interface) {
+		if typ.Implements(customSaveLoader) || typ.Kind() == reflect.Interface {
+			return nil
+		}
+		typ = typ.Elem()
+	}
+	if typ != nil && reflect.PointerTo(typ).Implements(customSaveLoader) {
+		return nil
+	}
+	return typ
+}
+
+func childType(typ reflect.Type, key string) reflect.Type {
+	switch {
+	case typ == nil:
+		return nil
+	case typ.Kind() == reflect.Map:
+		return typ.Elem()
+	case typ.Kind() == reflect.Struct:
+		if f, ok := typ.FieldByName(key); ok {
+			return f.Type
+		}
+	}
+	return nil
+}
+
+func elemType(typ reflect.Type) reflect.Type {
+	if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+		return typ.Elem()
+	}
+	return nil
+}
+
+func childPath(path string, typ reflect.Type, key string) string {
+	if typ != nil && typ.Kind() == reflect.Map {
+		return path + "[" + key + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func indexPath(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
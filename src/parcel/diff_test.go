@@ -0,0 +1,122 @@
+package parcel_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+type weapon struct {
+	Name   string
+	Damage int
+}
+
+type inventory struct {
+	Owner   *testType
+	Weapons []weapon
+	Counts  map[string]int
+	Data    []byte
+}
+
+func newInventory() *inventory {
+	return &inventory{
+		Weapons: []weapon{{"sword", 10}, {"bow", 5}},
+		Counts:  map[string]int{"arrows": 20, "potions": 2},
+		Data:    []byte("abc"),
+	}
+}
+
+func TestDiff(t *testing.T) {
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{})
+	p.AddType(&inventory{})
+	owner := &testType{String: "owner"}
+	p.SetSavePath(owner, "owner")
+
+	a := newInventory()
+	b := newInventory()
+	b.Owner = owner
+	b.Weapons[1].Damage = 7
+	b.Weapons = append(b.Weapons, weapon{"axe", 12})
+	delete(b.Counts, "potions")
+	b.Counts["bombs"] = 1
+
+	changes, err := p.Diff(a, b)
+	assert.NoError(t, err)
+	assert.Equal(t, []parcel.Change{
+		{Path: "Owner", Kind: parcel.Added, New: "owner.parcel"},
+		{Path: "Weapons[1].Damage", Kind: parcel.Modified, Old: json.Number("5"), New: json.Number("7")},
+		{Path: "Weapons[2]", Kind: parcel.Added, New: map[string]any{"Name": "axe", "Damage": json.Number("12")}},
+		{Path: "Counts[bombs]", Kind: parcel.Added, New: json.Number("1")},
+		{Path: "Counts[potions]", Kind: parcel.Removed, Old: json.Number("2")},
+	}, changes)
+
+	changes, err = p.Diff(a, newInventory())
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestDiffData(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&inventory{})
+
+	inv := newInventory()
+	p.SetSavePath(inv, "inv")
+	before, _ := store.ReadFile("inv.parcel")
+	inv.Counts["arrows"] = 19
+	p.Save(inv)
+	after, _ := store.ReadFile("inv.parcel")
+
+	changes, err := p.DiffData(before, after)
+	assert.NoError(t, err)
+	assert.Equal(t, []parcel.Change{
+		{Path: "Obj.Counts[arrows]", Kind: parcel.Modified, Old: json.Number("20"), New: json.Number("19")},
+	}, changes)
+}
+
+func TestMerge(t *testing.T) {
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{})
+	p.AddType(&inventory{})
+
+	base := newInventory()
+	ours := newInventory()
+	theirs := newInventory()
+
+	ours.Weapons[0].Damage = 11
+	ours.Counts["bombs"] = 3
+	theirs.Weapons[1].Name = "longbow"
+	theirs.Data = []byte("xyz")
+	delete(theirs.Counts, "potions")
+	// both sides change the same value
+	ours.Counts["arrows"] = 30
+	theirs.Counts["arrows"] = 40
+
+	mergedAny, conflicts, err := p.Merge(base, ours, theirs)
+	assert.NoError(t, err)
+	merged := mergedAny.(*inventory)
+	assert.Equal(t, []weapon{{"sword", 11}, {"longbow", 5}}, merged.Weapons)
+	assert.Equal(t, map[string]int{"arrows": 30, "bombs": 3}, merged.Counts)
+	assert.Equal(t, []byte("xyz"), merged.Data)
+	assert.Equal(t, []parcel.Conflict{
+		{Path: "Counts[arrows]", Base: json.Number("20"), Ours: json.Number("30"), Theirs: json.Number("40")},
+	}, conflicts)
+}
+
+func TestMergeData(t *testing.T) {
+	p := parcel.NewParcel()
+	base := []byte(`{"Type":"*parcel_test.inventory","Obj":{"Weapons":[{"Name":"sword","Damage":10}]}}`)
+	ours := []byte(`{"Type":"*parcel_test.inventory","Obj":{"Weapons":[{"Name":"sword","Damage":12}]}}`)
+	theirs := []byte(`{"Type":"*parcel_test.inventory","Obj":{"Weapons":[{"Name":"blade","Damage":10}]}}`)
+
+	merged, conflicts, err := p.MergeData(base, ours, theirs)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.JSONEq(t, `{"Type":"*parcel_test.inventory","Obj":{"Weapons":[{"Name":"blade","Damage":12}]}}`, string(merged))
+
+	_, _, err = p.MergeData(base, []byte("{"), theirs)
+	assert.Error(t, err)
+}
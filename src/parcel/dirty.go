@@ -0,0 +1,57 @@
+package parcel
+
+import (
+	"errors"
+	"slices"
+)
+
+// markClean records the canonical form of the asset at path as it was
+// last loaded or saved.
+func (p *Parcel) markClean(path string, canonical []byte) {
+	p.cleanHash[path] = hashData(canonical)
+}
+
+// IsDirty reports whether obj has changes that have not been saved.
+// Objects that have never been loaded or saved are always dirty.
+func (p *Parcel) IsDirty(obj any) bool {
+	path, exists := p.pathOf(obj)
+	if !exists {
+		return true
+	}
+	clean, known := p.cleanHash[path]
+	if !known {
+		return true
+	}
+	hash, err := p.Hash(obj)
+	return err != nil || hash != clean
+}
+
+// DirtyAssets returns the sorted paths of all loaded assets that have
+// unsaved changes.
+func (p *Parcel) DirtyAssets() []string {
+	var dirty []string
+	for path, obj := range p.loadedObjects() {
+		if p.IsDirty(obj) {
+			dirty = append(dirty, path)
+		}
+	}
+	slices.Sort(dirty)
+	return dirty
+}
+
+// SaveAllDirty saves every loaded asset that has unsaved changes and
+// returns the paths that were saved.  Saving continues past failures,
+// all errors are returned together.
+func (p *Parcel) SaveAllDirty() ([]string, error) {
+	var saved []string
+	var errs []error
+	for _, path := range p.DirtyAssets() {
+		obj, _ := p.objectAt(path)
+		if err := p.Save(obj); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		saved = append(saved, path)
+	}
+	return saved, errors.Join(errs...)
+}
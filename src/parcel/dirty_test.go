@@ -0,0 +1,55 @@
+package parcel_test
+
+import (
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirtyTracking(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+
+	a := &testType{String: "a"}
+	b := &testType{String: "b"}
+	assert.True(t, p.IsDirty(a), "unsaved objects are dirty")
+	p.SetSavePath(a, "a")
+	p.SetSavePath(b, "b")
+	assert.False(t, p.IsDirty(a))
+	assert.Empty(t, p.DirtyAssets())
+
+	b.String = "changed"
+	assert.True(t, p.IsDirty(b))
+	assert.Equal(t, []string{"b.parcel"}, p.DirtyAssets())
+	b.String = "b"
+	assert.False(t, p.IsDirty(b), "reverting a change makes the object clean")
+
+	// loaded objects start clean
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	loadedAny, err := p2.Load(&testType{}, "a")
+	assert.NoError(t, err)
+	loaded := loadedAny.(*testType)
+	assert.False(t, p2.IsDirty(loaded))
+
+	loaded.Float = 3
+	saved, err := p2.SaveAllDirty()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.parcel"}, saved)
+	assert.False(t, p2.IsDirty(loaded))
+	assert.Empty(t, p2.DirtyAssets())
+}
+
+func TestDirtyTrackingDefault(t *testing.T) {
+	setupBasic(newDefault(), setupOpts{})
+	obj, _ := parcel.New[testType]()
+	parcel.SetSavePath(obj, "a")
+
+	obj.String = "changed"
+	assert.Equal(t, []string{"a.parcel"}, parcel.DirtyAssets())
+	saved, err := parcel.SaveAllDirty()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.parcel"}, saved)
+	assert.False(t, parcel.IsDirty(obj))
+}
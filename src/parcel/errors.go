@@ -0,0 +1,93 @@
+package parcel
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Errors returned by a Parcel wrap one of these values, so they can be
+// tested with errors.Is.  Most are returned inside an *Error or a
+// *DecodeError that says which asset was involved.
+var (
+	ErrNotFound     = fmt.Errorf("asset not found: %w", fs.ErrNotExist)
+	ErrUnknownType  = errors.New("unknown asset type, make sure this type is added")
+	ErrInvalidType  = errors.New("invalid asset type")
+	ErrNoWritableFS = errors.New("no WritableFS has been registered")
+	ErrNoSavePath   = errors.New("object has no save path, call SetSavePath first")
+	ErrPathExists   = fmt.Errorf("path already exists: %w", fs.ErrExist)
+	ErrFieldPath    = errors.New("invalid field path")
+	ErrRequired     = errors.New("required field is missing")
+)
+
+// Error records a failed operation on an asset.
+type Error struct {
+	Op   string
+	Path string
+	Type string
+	// Chain holds the assets that were being loaded when the error
+	// occurred, outermost first.  The last one referenced Path.
+	Chain []string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return describeError(e.Op, e.Path, e.Type, e.Chain, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError reports saved data that could not be decoded into the
+// asset's type.
+type DecodeError struct {
+	Path  string
+	Type  string
+	Chain []string
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	return describeError("decode", e.Path, e.Type, e.Chain, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func describeError(op string, path string, typ string, chain []string, err error) string {
+	var b strings.Builder
+	b.WriteString(op)
+	if path != "" {
+		b.WriteString(" " + path)
+	}
+	if typ != "" {
+		b.WriteString(" (" + typ + ")")
+	}
+	b.WriteString(": " + err.Error())
+	if len(chain) > 0 {
+		b.WriteString(" (referenced by " + strings.Join(chain, " > ") + ")")
+	}
+	return b.String()
+}
+
+// newError builds an *Error, capturing the chain of assets currently
+// being loaded.
+func (p *Parcel) newError(op string, path string, typ reflect.Type, err error) *Error {
+	e := &Error{Op: op, Path: path, Chain: slices.Clone(p.loadStack), Err: err}
+	if typ != nil {
+		e.Type = typeStr(typ)
+	}
+	return e
+}
+
+// isAssetError reports whether err already describes which asset failed.
+func isAssetError(err error) bool {
+	var e *Error
+	var de *DecodeError
+	return errors.As(err, &e) || errors.As(err, &de)
+}
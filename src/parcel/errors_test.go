@@ -0,0 +1,49 @@
+package parcel_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorValues(t *testing.T) {
+	p := parcel.NewParcel()
+
+	err := p.Save(&testType{})
+	assert.ErrorIs(t, err, parcel.ErrNoWritableFS)
+
+	store := setupBasic(p, setupOpts{})
+	err = p.Save(&testType{})
+	assert.ErrorIs(t, err, parcel.ErrNoSavePath)
+
+	_, err = p.New(&basicTypes{})
+	assert.ErrorIs(t, err, parcel.ErrUnknownType)
+
+	assert.ErrorIs(t, p.AddType(testType{}), parcel.ErrInvalidType)
+
+	p.SetSavePath(&testType{}, "taken")
+	err = p.SetSavePath(&testType{}, "taken")
+	assert.ErrorIs(t, err, parcel.ErrPathExists)
+	assert.ErrorIs(t, err, fs.ErrExist)
+	var perr *parcel.Error
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "taken.parcel", perr.Path)
+	assert.Equal(t, "*parcel_test.testType", perr.Type)
+
+	_, err = p.Load(&testType{}, "missing")
+	assert.ErrorIs(t, err, parcel.ErrNotFound)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	store.WriteFile("corrupt.parcel", []byte(`{"Type":"*parcel_test.testType","Obj":{"String":5}}`))
+	store.WriteFile("refs_corrupt.parcel", []byte(`{"Type":"*parcel_test.testType","Obj":{"OtherObj":"corrupt"}}`))
+	_, err = p.Load(&testType{}, "refs_corrupt")
+	var derr *parcel.DecodeError
+	assert.True(t, errors.As(err, &derr))
+	assert.Equal(t, "corrupt.parcel", derr.Path)
+	assert.Equal(t, []string{"refs_corrupt.parcel"}, derr.Chain)
+	_, err = p.Load(&testType{}, "refs_corrupt")
+	assert.Error(t, err, "failed loads are not cached")
+}
@@ -0,0 +1,92 @@
+package parcel
+
+import (
+	"reflect"
+	"slices"
+)
+
+// extraField is a field read from an asset that its Go struct does not
+// have.  Extra fields are written back when the asset is saved, so that
+// files edited by hand or by newer tools survive older tools.
+type extraField struct {
+	key   string
+	value *node
+}
+
+// extraFields maps the field path of a struct within an asset, such as
+// "Obj.Weapons[1]", to the fields that struct did not know about.
+type extraFields map[string][]extraField
+
+// captureExtras records the fields in canonical that typ does not have.
+// A nil result means there were none.
+func captureExtras(typ reflect.Type, canonical []byte) (extraFields, error) {
+	n, err := parseNode(canonical)
+	if err != nil {
+		return nil, err
+	}
+	extras := extraFields{}
+	walkStructs(n, "", typ, func(path string, obj *node, plan *typePlan) {
+		for _, k := range obj.keys {
+			if _, known := plan.byName[k]; !known {
+				extras[path] = append(extras[path], extraField{key: k, value: obj.fields[k]})
+			}
+		}
+	})
+	if len(extras) == 0 {
+		return nil, nil
+	}
+	return extras, nil
+}
+
+// mergeExtras adds extras back into canonical, which was saved from typ.
+// Fields are only added to structs that are still at the same path and
+// do not already have a field with that name.
+func mergeExtras(typ reflect.Type, canonical []byte, extras extraFields) ([]byte, error) {
+	n, err := parseNode(canonical)
+	if err != nil {
+		return nil, err
+	}
+	walkStructs(n, "", typ, func(path string, obj *node, plan *typePlan) {
+		for _, extra := range extras[path] {
+			if _, exists := obj.fields[extra.key]; !exists {
+				obj.keys = append(obj.keys, extra.key)
+				obj.fields[extra.key] = extra.value
+			}
+		}
+	})
+	return n.bytes(), nil
+}
+
+// walkStructs calls fn for every object in n that was saved from a
+// struct, using the same paths as Diff.
+func walkStructs(n *node, path string, typ reflect.Type, fn func(path string, obj *node, plan *typePlan)) {
+	typ = serializedType(typ)
+	if n == nil || typ == nil {
+		return
+	}
+	switch n.kind {
+	case objectNode:
+		switch typ.Kind() {
+		case reflect.Struct:
+			plan := planFor(typ)
+			if plan.custom {
+				return
+			}
+			// fn may add keys, only walk the ones that were there
+			for _, k := range slices.Clone(n.keys) {
+				if _, known := plan.byName[k]; known {
+					walkStructs(n.fields[k], childPath(path, typ, k), childType(typ, k), fn)
+				}
+			}
+			fn(path, n, plan)
+		case reflect.Map:
+			for _, k := range n.keys {
+				walkStructs(n.fields[k], childPath(path, typ, k), typ.Elem(), fn)
+			}
+		}
+	case arrayNode:
+		for i, e := range n.elems {
+			walkStructs(e, indexPath(path, i), elemType(typ), fn)
+		}
+	}
+}
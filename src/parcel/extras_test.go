@@ -0,0 +1,80 @@
+package parcel_test
+
+import (
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+type extrasItem struct {
+	Name string
+}
+
+type extrasAsset struct {
+	Name   string
+	Items  []extrasItem
+	Lookup map[string]extrasItem
+}
+
+func TestUnknownFieldsSurviveSave(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&extrasAsset{})
+	store.WriteFile("a.parcel", []byte(`{"Type":"*parcel_test.extrasAsset","Parent":"","Obj":{`+
+		`"Name":"a","Future":{"x":[1,2]},`+
+		`"Items":[{"Name":"i0","Color":"red"},{"Name":"i1"}],`+
+		`"Lookup":{"k":{"Name":"v","Weight":1.50}}}}`))
+
+	obj, err := p.Load(&extrasAsset{}, "a")
+	assert.NoError(t, err)
+	a := obj.(*extrasAsset)
+	assert.False(t, p.IsDirty(a))
+	a.Name = "renamed"
+	a.Items = append(a.Items, extrasItem{Name: "i2"})
+	assert.NoError(t, p.Save(a))
+	assert.False(t, p.IsDirty(a))
+
+	data, _ := store.ReadFile("a.parcel")
+	assert.Equal(t, `{"Type":"*parcel_test.extrasAsset","Parent":"","Obj":{`+
+		`"Name":"renamed",`+
+		`"Items":[{"Name":"i0","Color":"red"},{"Name":"i1"},{"Name":"i2"}],`+
+		`"Lookup":{"k":{"Name":"v","Weight":1.50}},"Future":{"x":[1,2]}}}`, string(data))
+
+	// removed map entries are not brought back
+	delete(a.Lookup, "k")
+	assert.NoError(t, p.Save(a))
+	data, _ = store.ReadFile("a.parcel")
+	assert.NotContains(t, string(data), "Weight")
+	assert.Contains(t, string(data), `"Future"`)
+
+	// extras follow a move and are dropped with Unload
+	assert.NoError(t, p.Move("a", "b"))
+	data, _ = store.ReadFile("b.parcel")
+	assert.Contains(t, string(data), `"Future"`)
+	p.Unload("b")
+	assert.NoError(t, p.SetSavePath(a, "b"))
+	data, _ = store.ReadFile("b.parcel")
+	assert.NotContains(t, string(data), `"Future"`)
+}
+
+func TestUnknownFieldsGenerated(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&genWeapon{})
+	p.AddType(&genLevel{})
+	w := &genWeapon{Name: "sword"}
+	assert.NoError(t, p.SetSavePath(w, "w"))
+	data, _ := store.ReadFile("w.parcel")
+	edited := string(data[:len(data)-2]) + `,"Rarity":"epic"}}`
+	store.WriteFile("w.parcel", []byte(edited))
+
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	p2.AddType(&genWeapon{})
+	obj, err := p2.Load(&genWeapon{}, "w")
+	assert.NoError(t, err)
+	assert.NoError(t, p2.Save(obj))
+	data, _ = store.ReadFile("w.parcel")
+	assert.Equal(t, edited, string(data))
+}
@@ -0,0 +1,352 @@
+package parcel
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/launchdarkly/go-jsonstream/v3/jreader"
+)
+
+/*
+Field paths name a value inside an asset the same way Diff does.
+Struct fields are separated by dots and slice indexes and map keys are
+in brackets, for example
+
+	Stats.Weapons[2].Damage
+	Drops[gold].Chance
+
+Only the exported fields that are saved can be named.  Pointers and
+interfaces are followed, so a path can reach into a referenced asset.
+*/
+
+// pathStep is one step of a field path, a field name or the index or
+// map key written inside brackets.
+type pathStep struct {
+	name    string
+	bracket bool
+}
+
+func parseFieldPath(path string) ([]pathStep, error) {
+	var steps []pathStep
+	for i := 0; i < len(path); {
+		switch {
+		case path[i] == '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%w %s: missing ]", ErrFieldPath, path)
+			}
+			steps = append(steps, pathStep{name: path[i+1 : i+end], bracket: true})
+			i += end + 1
+		case path[i] == '.' && len(steps) == 0, path[i] == '.' && i+1 == len(path):
+			return nil, fmt.Errorf("%w %s: empty field name", ErrFieldPath, path)
+		default:
+			if path[i] == '.' {
+				i++
+			} else if len(steps) > 0 {
+				return nil, fmt.Errorf("%w %s: expected . or [ at offset %d", ErrFieldPath, path, i)
+			}
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("%w %s: empty field name", ErrFieldPath, path)
+			}
+			steps = append(steps, pathStep{name: path[i : i+end]})
+			i += end
+		}
+	}
+	return steps, nil
+}
+
+// fieldWalker finds the value at a field path.  When create is set,
+// nil pointers, nil maps and missing map keys along the way are filled
+// in and a slice index one past the end appends, so that the value can
+// be set.
+type fieldWalker struct {
+	p      *Parcel
+	path   string
+	create bool
+}
+
+func (p *Parcel) visitField(obj any, path string, create bool, fn func(v reflect.Value) error) error {
+	steps, err := parseFieldPath(path)
+	if err != nil {
+		return err
+	}
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("%w: field paths need a non nil pointer, not %T", ErrInvalidType, obj)
+	}
+	w := &fieldWalker{p: p, path: path, create: create}
+	return w.visit(v.Elem(), steps, fn)
+}
+
+func (w *fieldWalker) errorf(msg string, args ...any) error {
+	return fmt.Errorf("%w %s: %s", ErrFieldPath, w.path, fmt.Sprintf(msg, args...))
+}
+
+func (w *fieldWalker) visit(v reflect.Value, steps []pathStep, fn func(v reflect.Value) error) error {
+	if len(steps) == 0 {
+		return fn(v)
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if !w.create || v.Kind() == reflect.Interface || !v.CanSet() {
+				return w.errorf("nil %s before %s", v.Type(), steps[0].name)
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	step := steps[0]
+	switch v.Kind() {
+	case reflect.Struct:
+		field, ok := planFor(v.Type()).byName[step.name]
+		if step.bracket || !ok {
+			return w.errorf("%s has no field %s", v.Type(), step.name)
+		}
+		fv, err := v.FieldByIndexErr(field.index)
+		if err != nil {
+			return w.errorf("%v", err)
+		}
+		return w.visit(fv, steps[1:], fn)
+
+	case reflect.Slice, reflect.Array:
+		i, err := strconv.Atoi(step.name)
+		if !step.bracket || err != nil || i < 0 {
+			return w.errorf("%s needs an index, not %s", v.Type(), step.name)
+		}
+		if i == v.Len() && w.create && v.Kind() == reflect.Slice && v.CanSet() {
+			v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+		}
+		if i >= v.Len() {
+			return w.errorf("index %d out of range, length is %d", i, v.Len())
+		}
+		return w.visit(v.Index(i), steps[1:], fn)
+
+	case reflect.Map:
+		if !step.bracket {
+			return w.errorf("%s needs a key in brackets, not %s", v.Type(), step.name)
+		}
+		loadKey, err := w.p.makeKeyLoader(v.Type().Key())
+		if err != nil {
+			return w.errorf("%v", err)
+		}
+		k, err := loadKey(step.name)
+		if err != nil {
+			return w.errorf("bad key %s: %v", step.name, err)
+		}
+		elem := v.MapIndex(k)
+		if !elem.IsValid() {
+			if !w.create {
+				return w.errorf("no key %s", step.name)
+			}
+			elem = reflect.Zero(v.Type().Elem())
+		}
+		// map elements cannot be changed in place, change a copy and
+		// store it back
+		cp := reflect.New(v.Type().Elem()).Elem()
+		cp.Set(elem)
+		if err := w.visit(cp, steps[1:], fn); err != nil {
+			return err
+		}
+		if w.create && v.CanSet() {
+			if v.IsNil() {
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+			v.SetMapIndex(k, cp)
+		}
+		return nil
+	}
+	return w.errorf("%s has no fields", v.Type())
+}
+
+// GetField returns the value at path in obj, which must be a non nil
+// pointer.
+func (p *Parcel) GetField(obj any, path string) (any, error) {
+	var value any
+	err := p.visitField(obj, path, false, func(v reflect.Value) error {
+		if !v.CanInterface() {
+			return fmt.Errorf("%w %s: value cannot be read", ErrFieldPath, path)
+		}
+		value = v.Interface()
+		return nil
+	})
+	return value, err
+}
+
+// SetField sets the value at path in obj, which must be a non nil
+// pointer.  It follows the same rules as SetProperty.
+func (p *Parcel) SetField(obj any, path string, value any) error {
+	return p.setPath(obj, path, value)
+}
+
+func (p *Parcel) setPath(obj any, path string, value any) error {
+	return p.visitField(obj, path, true, func(v reflect.Value) error {
+		if err := p.setValue(v, value); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// setValue sets v to value.  A json.RawMessage is decoded the way Load
+// decodes the field.  A string set on a pointer or interface is the path
+// of the asset to refer to, a string set on a number or bool is parsed
+// the way a default= tag is, otherwise strings are used as they are.
+func (p *Parcel) setValue(v reflect.Value, value any) error {
+	switch value := value.(type) {
+	case json.RawMessage:
+		return p.setFromJSON(v, value)
+	case string:
+		switch k := v.Kind(); {
+		case k == reflect.Pointer || k == reflect.Interface:
+			quoted, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			return p.setFromJSON(v, quoted)
+		case k == reflect.Bool || isNumber(k):
+			return p.setFromTag(v, value)
+		}
+	}
+	return assign(v, value)
+}
+
+// setFromTag sets v from the value of a struct tag option such as
+// default=.  The value is decoded as JSON, or as a JSON string if it is
+// not JSON, so both default=42 and default=walk work.
+func (p *Parcel) setFromTag(v reflect.Value, s string) error {
+	if v.Kind() == reflect.String {
+		return assign(v, s)
+	}
+	fragment := []byte(s)
+	if !json.Valid(fragment) {
+		fragment = []byte(strconv.Quote(s))
+	}
+	return p.setFromJSON(v, fragment)
+}
+
+// setFromJSON replaces v with the JSON value in fragment.
+func (p *Parcel) setFromJSON(v reflect.Value, fragment []byte) error {
+	if !v.CanSet() {
+		return fmt.Errorf("%w: %s cannot be set", ErrInvalidType, v.Type())
+	}
+	decoded := reflect.New(v.Type()).Elem()
+	if err := p.decodeFragment(decoded, fragment); err != nil {
+		return err
+	}
+	v.Set(decoded)
+	return nil
+}
+
+// decodeFragment decodes the JSON value data into v, the way a struct
+// field holding v is loaded.
+func (p *Parcel) decodeFragment(v reflect.Value, data []byte) error {
+	r := jreader.NewReader(data)
+	pr := &preader{r: &r}
+	if err := p.loadField(pr, v); err != nil {
+		return err
+	}
+	if err := r.Error(); err != nil {
+		return err
+	}
+	return r.RequireEOF()
+}
+
+// assign sets dst to value.  Numbers are converted between kinds as
+// long as the value fits, and nil sets the zero value.
+func assign(dst reflect.Value, value any) error {
+	if !dst.CanSet() {
+		return fmt.Errorf("%w: %s cannot be set", ErrInvalidType, dst.Type())
+	}
+	if value == nil {
+		dst.SetZero()
+		return nil
+	}
+	src := reflect.ValueOf(value)
+	switch {
+	case src.Type().AssignableTo(dst.Type()):
+		dst.Set(src)
+		return nil
+	case isNumber(src.Kind()) && isNumber(dst.Kind()):
+		return setNumber(dst, src)
+	case src.Kind() == dst.Kind() && src.Type().ConvertibleTo(dst.Type()):
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("%w: cannot assign %s to %s", ErrInvalidType, src.Type(), dst.Type())
+}
+
+func isNumber(k reflect.Kind) bool {
+	return (k >= reflect.Int && k <= reflect.Uintptr) || k == reflect.Float32 || k == reflect.Float64
+}
+
+func setNumber(dst reflect.Value, src reflect.Value) error {
+	overflow := fmt.Errorf("%w: %v does not fit in %s", ErrInvalidType, src, dst.Type())
+	switch {
+	case dst.CanInt():
+		var n int64
+		switch {
+		case src.CanInt():
+			n = src.Int()
+		case src.CanUint():
+			if src.Uint() > math.MaxInt64 {
+				return overflow
+			}
+			n = int64(src.Uint())
+		default:
+			f := src.Float()
+			if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return overflow
+			}
+			n = int64(f)
+		}
+		if dst.OverflowInt(n) {
+			return overflow
+		}
+		dst.SetInt(n)
+	case dst.CanUint():
+		var n uint64
+		switch {
+		case src.CanInt():
+			if src.Int() < 0 {
+				return overflow
+			}
+			n = uint64(src.Int())
+		case src.CanUint():
+			n = src.Uint()
+		default:
+			f := src.Float()
+			if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+				return overflow
+			}
+			n = uint64(f)
+		}
+		if dst.OverflowUint(n) {
+			return overflow
+		}
+		dst.SetUint(n)
+	default:
+		var f float64
+		switch {
+		case src.CanInt():
+			f = float64(src.Int())
+		case src.CanUint():
+			f = float64(src.Uint())
+		default:
+			f = src.Float()
+		}
+		if dst.OverflowFloat(f) {
+			return overflow
+		}
+		dst.SetFloat(f)
+	}
+	return nil
+}
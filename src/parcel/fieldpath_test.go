@@ -0,0 +1,99 @@
+package parcel_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+type pathWeapon struct {
+	Name   string
+	Damage int
+}
+
+type pathStats struct {
+	Weapons []pathWeapon
+	ByLevel map[int]float64
+}
+
+type pathAsset struct {
+	Stats   pathStats
+	Owner   *testType
+	Data    []byte
+	Enabled bool
+	Any     any
+	hidden  int
+}
+
+func TestGetField(t *testing.T) {
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{})
+	obj := &pathAsset{Stats: pathStats{
+		Weapons: []pathWeapon{{Name: "a"}, {Name: "b", Damage: 7}},
+		ByLevel: map[int]float64{3: 1.5},
+	}}
+
+	v, err := p.GetField(obj, "Stats.Weapons[1].Damage")
+	assert.NoError(t, err)
+	assert.Equal(t, 7, v)
+	v, err = p.GetField(obj, "Stats.ByLevel[3]")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, v)
+	v, err = p.GetField(obj, "")
+	assert.NoError(t, err)
+	assert.Equal(t, *obj, v)
+
+	for _, path := range []string{"Stats.Weapons[2]", "Stats.ByLevel[4]", "Stats.ByLevel[x]", "hidden", "Owner.String", "Stats.Weapons.Name", "Stats["} {
+		_, err := p.GetField(obj, path)
+		assert.ErrorIs(t, err, parcel.ErrFieldPath, path)
+	}
+	assert.Nil(t, obj.Owner, "getting does not create values")
+	assert.Len(t, obj.Stats.ByLevel, 1)
+}
+
+func TestSetField(t *testing.T) {
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{})
+	owner := &testType{String: "owner"}
+	assert.NoError(t, p.SetSavePath(owner, "owner"))
+	obj := &pathAsset{}
+
+	assert.NoError(t, p.SetField(obj, "Stats.Weapons[0].Name", "sword"))
+	assert.NoError(t, p.SetField(obj, "Stats.Weapons[0].Damage", 42))
+	assert.NoError(t, p.SetField(obj, "Stats.Weapons[1]", json.RawMessage(`{"Name":"axe","Damage":3}`)))
+	assert.Equal(t, []pathWeapon{{"sword", 42}, {"axe", 3}}, obj.Stats.Weapons)
+
+	assert.NoError(t, p.SetField(obj, "Stats.ByLevel[2]", 0.5))
+	assert.Equal(t, map[int]float64{2: 0.5}, obj.Stats.ByLevel)
+	assert.NoError(t, p.SetField(obj, "Stats.ByLevel", json.RawMessage(`{"1":2}`)))
+	assert.Equal(t, map[int]float64{1: 2}, obj.Stats.ByLevel)
+
+	assert.NoError(t, p.SetField(obj, "Owner", "owner"))
+	assert.Same(t, owner, obj.Owner)
+	assert.NoError(t, p.SetField(obj, "Any", "owner.parcel"))
+	assert.Same(t, owner, obj.Any)
+	assert.NoError(t, p.SetField(obj, "Data", json.RawMessage(`"AAEC"`)))
+	assert.Equal(t, []byte{0, 1, 2}, obj.Data)
+	assert.NoError(t, p.SetField(obj, "Enabled", true))
+	assert.True(t, obj.Enabled)
+	assert.NoError(t, p.SetField(obj, "Stats.Weapons[0].Damage", int64(5)))
+	assert.Equal(t, 5, obj.Stats.Weapons[0].Damage)
+
+	// strings are parsed for numbers and bools, and are paths for references
+	assert.NoError(t, p.SetField(obj, "Stats.Weapons[0].Damage", "42"))
+	assert.Equal(t, 42, obj.Stats.Weapons[0].Damage)
+	assert.NoError(t, p.SetField(obj, "Enabled", "false"))
+	assert.False(t, obj.Enabled)
+	assert.Error(t, p.SetField(obj, "Stats.Weapons[0].Damage", "sharp"))
+	assert.Error(t, p.SetField(obj, "Stats.Weapons[0].Damage", "4.5"))
+	assert.NoError(t, p.SetField(obj, "Stats.Weapons[0].Damage", int64(5)))
+	assert.Error(t, p.SetField(obj, "Owner", "null"), "null is a path, not a way to clear")
+	assert.Same(t, owner, obj.Owner)
+	assert.NoError(t, p.SetField(obj, "Stats.Weapons[0].Name", `{"a":1}`))
+	assert.Equal(t, `{"a":1}`, obj.Stats.Weapons[0].Name)
+	assert.Error(t, p.SetField(obj, "Stats.Weapons[0].Damage", json.RawMessage("1 2")))
+	assert.ErrorIs(t, p.SetField(obj, "Stats.Weapons[5].Damage", 1), parcel.ErrFieldPath)
+	assert.Equal(t, 5, obj.Stats.Weapons[0].Damage)
+}
@@ -0,0 +1,115 @@
+package parcel_test
+
+import (
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+type rulesStats struct {
+	Speed float32 `parcel:"default=1.5"`
+	Mode  string  `parcel:"default=walk"`
+}
+
+type rulesAsset struct {
+	Name   string   `parcel:"required"`
+	Health int      `parcel:"default=100"`
+	Tags   []string `parcel:"default='[\"new\"]'"`
+	Stats  rulesStats
+}
+
+func TestFieldDefaults(t *testing.T) {
+	for _, T := range []any{&rulesAsset{}, &genRules{}} {
+		p := parcel.NewParcel()
+		store := setupBasic(p, setupOpts{})
+		assert.NoError(t, p.AddType(T))
+		typeName := "*parcel_test.rulesAsset"
+		if _, generated := T.(*genRules); generated {
+			typeName = "*parcel_test.genRules"
+		}
+
+		store.WriteFile("old.parcel", []byte(`{"Type":"`+typeName+`","Obj":{"Name":"old","Stats":{"Mode":"run"}}}`))
+		obj, err := p.Load(T, "old")
+		assert.NoError(t, err)
+		got, _ := p.GetField(obj, "")
+		assert.EqualValues(t, rulesAsset{Name: "old", Health: 100, Tags: []string{"new"}, Stats: rulesStats{Speed: 1.5, Mode: "run"}},
+			asRules(got), typeName)
+
+		store.WriteFile("set.parcel", []byte(`{"Type":"`+typeName+`","Obj":{"Name":"set","Health":0,"Tags":[]}}`))
+		obj, err = p.Load(T, "set")
+		assert.NoError(t, err)
+		got, _ = p.GetField(obj, "")
+		assert.EqualValues(t, rulesAsset{Name: "set", Stats: rulesStats{Speed: 1.5, Mode: "walk"}},
+			asRules(got), "present values are kept and missing structs get their defaults")
+
+		store.WriteFile("nameless.parcel", []byte(`{"Type":"`+typeName+`","Obj":{"Health":5}}`))
+		_, err = p.Load(T, "nameless")
+		assert.ErrorIs(t, err, parcel.ErrRequired)
+		assert.ErrorContains(t, err, "field Name")
+	}
+
+	p := parcel.NewParcel()
+	p.AddType(&rulesAsset{})
+	schema, err := p.JSONSchema(&rulesAsset{})
+	assert.NoError(t, err)
+	assert.Contains(t, string(schema), `"required": [
+        "Name"
+      ]`)
+}
+
+func asRules(v any) rulesAsset {
+	switch v := v.(type) {
+	case genRules:
+		return rulesAsset(v)
+	case rulesAsset:
+		return v
+	}
+	return rulesAsset{}
+}
+
+type badDefault struct {
+	Count int `parcel:"default=many"`
+}
+
+type requiredDefault struct {
+	Name string `parcel:"required,default=x"`
+}
+
+type requiredReference struct {
+	Owner *testType `parcel:"required"`
+	Any   describer `parcel:"default=owner"`
+}
+
+func TestFieldRulesChecked(t *testing.T) {
+	p := parcel.NewParcel()
+	err := p.AddType(&badDefault{})
+	assert.ErrorIs(t, err, parcel.ErrInvalidType)
+	assert.ErrorContains(t, err, `field Count`)
+	assert.ErrorIs(t, p.AddType(&requiredDefault{}), parcel.ErrInvalidType)
+
+	// nil references are not saved, so they would look missing
+	err = p.AddType(&requiredReference{})
+	assert.ErrorIs(t, err, parcel.ErrInvalidType)
+	assert.ErrorContains(t, err, "field Owner: invalid asset type: ptr fields cannot be required")
+	assert.ErrorContains(t, err, "field Any: invalid asset type: interface fields cannot be required or have a default")
+}
+
+func TestFieldDefaultsOverrideFactory(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddFactoryForType(&rulesAsset{}, func() (any, error) {
+		return &rulesAsset{Health: 50, Stats: rulesStats{Mode: "fly"}}, nil
+	})
+
+	created, err := p.New(&rulesAsset{})
+	assert.NoError(t, err)
+	assert.Equal(t, 50, created.(*rulesAsset).Health, "New keeps the factory values")
+
+	store.WriteFile("old.parcel", []byte(`{"Type":"*parcel_test.rulesAsset","Obj":{"Name":"old"}}`))
+	obj, err := p.Load(&rulesAsset{}, "old")
+	assert.NoError(t, err)
+	loaded := obj.(*rulesAsset)
+	assert.Equal(t, 100, loaded.Health, "a missing field gets its default, not the factory value")
+	assert.Equal(t, "walk", loaded.Stats.Mode)
+}
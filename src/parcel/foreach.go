@@ -0,0 +1,188 @@
+package parcel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ForEachOptions control a ForEach pass.
+type ForEachOptions struct {
+	// DryRun runs the callback on copies of the assets and saves
+	// nothing, so the report shows what would change.  Loaded objects
+	// are not touched.
+	DryRun bool
+	// ContinueOnError records assets that fail to load, save or that
+	// the callback returns an error for, and carries on with the rest.
+	ContinueOnError bool
+}
+
+// ForEachReport summarises a ForEach pass.  Paths are sorted.
+type ForEachReport struct {
+	// Visited holds the assets of the type that the callback ran on.
+	Visited []string
+	// Modified holds the assets the callback changed.  Unless the pass
+	// was a dry run they have been saved.
+	Modified []string
+	// Written holds the modified assets whose saved bytes changed.
+	Written []string
+	Failed  map[string]error
+	// Unreadable holds the assets whose Type header could not be read.
+	// They are skipped rather than failed, since they may be of any
+	// type.
+	Unreadable map[string]error
+	DryRun     bool
+}
+
+func (r *ForEachReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d visited, %d modified", len(r.Visited), len(r.Modified))
+	if r.DryRun {
+		b.WriteString(" (dry run)")
+	} else {
+		fmt.Fprintf(&b, ", %d written", len(r.Written))
+	}
+	if len(r.Failed) > 0 {
+		fmt.Fprintf(&b, ", %d failed", len(r.Failed))
+	}
+	if len(r.Unreadable) > 0 {
+		fmt.Fprintf(&b, ", %d unreadable", len(r.Unreadable))
+	}
+	return b.String()
+}
+
+// ForEach calls fn with every asset of type T in the registered
+// filesystems, including assets that only exist in lower layers, and
+// saves the ones fn reports as modified to the WritableFS.  Assets that
+// are already loaded are passed as they are in memory.  If fn returns an
+// error, its edits to the object are undone.
+func (p *Parcel) ForEach(T any, fn func(path string, obj any) (modified bool, err error), opts ForEachOptions) (*ForEachReport, error) {
+	typ := reflect.TypeOf(T)
+	if _, known := p.objectNewFunc[typ]; !known {
+		return nil, p.newError("foreach", "", typ, ErrUnknownType)
+	}
+	if p.writefs == nil && !opts.DryRun {
+		return nil, p.newError("foreach", "", typ, ErrNoWritableFS)
+	}
+	paths, err := p.Assets()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ForEachReport{Failed: map[string]error{}, Unreadable: map[string]error{}, DryRun: opts.DryRun}
+	for _, path := range paths {
+		err := p.forEachAsset(report, typ, path, fn, opts)
+		if err == nil {
+			continue
+		}
+		report.Failed[path] = err
+		if !opts.ContinueOnError {
+			return report, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if len(report.Failed) > 0 {
+		errs := make([]error, 0, len(report.Failed))
+		for _, path := range paths {
+			if err, failed := report.Failed[path]; failed {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			}
+		}
+		return report, errors.Join(errs...)
+	}
+	return report, nil
+}
+
+func (p *Parcel) forEachAsset(report *ForEachReport, typ reflect.Type, path string,
+	fn func(path string, obj any) (bool, error), opts ForEachOptions) error {
+	loaded, isLoaded := p.objectAt(path)
+	var data []byte
+	if isLoaded {
+		if reflect.TypeOf(loaded) != typ {
+			return nil
+		}
+	} else {
+		var err error
+		if data, err = p.ReadAsset(path); err != nil {
+			report.Unreadable[path] = err
+			return nil
+		}
+		name, err := headerType(data)
+		if err != nil {
+			report.Unreadable[path] = &DecodeError{Path: path, Err: err}
+			return nil
+		}
+		if headerTyp, known := p.typeByName(name); !known || headerTyp != typ {
+			return nil
+		}
+	}
+
+	obj := loaded
+	var err error
+	switch {
+	case opts.DryRun:
+		obj, err = p.detachedCopy(typ, loaded, data)
+	case !isLoaded:
+		obj, err = p.loadRead(reflect.Zero(typ).Interface(), path, data)
+	}
+	if err != nil {
+		return err
+	}
+	var before []byte
+	if !opts.DryRun {
+		if before, err = p.jsonSave(obj); err != nil {
+			return err
+		}
+	}
+	report.Visited = append(report.Visited, path)
+	modified, err := fn(path, obj)
+	if err != nil {
+		if before != nil {
+			if restoreErr := p.restore(obj, before); restoreErr != nil {
+				err = errors.Join(err, restoreErr)
+			}
+		}
+		return err
+	}
+	if !modified {
+		return nil
+	}
+	report.Modified = append(report.Modified, path)
+	if opts.DryRun {
+		return nil
+	}
+	written, err := p.SaveChanged(obj)
+	if written {
+		report.Written = append(report.Written, path)
+	}
+	return err
+}
+
+// detachedCopy decodes an asset into a new object that is not
+// registered at its path.  The copy is made from the loaded object if
+// there is one, otherwise from data.
+func (p *Parcel) detachedCopy(typ reflect.Type, loaded any, data []byte) (any, error) {
+	if loaded != nil {
+		var err error
+		if data, err = p.canonicalData(loaded); err != nil {
+			return nil, err
+		}
+	}
+	loadableType, err := p.getLoadableSaveFormatType(typ)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := p.newFromType(typ)
+	if err != nil {
+		obj = reflect.New(typ.Elem()).Interface()
+	}
+	loadableV := reflect.New(loadableType)
+	loadableV.Elem().FieldByName("Obj").Set(reflect.ValueOf(obj))
+	if err := p.jsonLoad(loadableV.Interface(), data); err != nil {
+		return nil, err
+	}
+	if planFor(typ).postLoad {
+		obj.(PostLoader).PostLoad()
+	}
+	return obj, nil
+}
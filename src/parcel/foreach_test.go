@@ -0,0 +1,123 @@
+package parcel_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+type balanceWeapon struct {
+	Name   string
+	Damage float64
+}
+
+func setupForEach(t *testing.T) (*parcel.Parcel, *parcel.MemoryFS, *parcel.MemoryFS) {
+	p := newDefault()
+	base := parcel.NewMemoryFS()
+	mods := parcel.NewMemoryFS()
+	p.RegisterFS(mods, 0)
+	p.RegisterFS(base, 10)
+	p.RegisterWriteableFS(mods)
+	p.AddType(&testType{})
+	p.AddType(&balanceWeapon{})
+	base.WriteFile("sword.parcel", []byte(`{"Type":"*parcel_test.balanceWeapon","Obj":{"Name":"sword","Damage":10}}`))
+	base.WriteFile("axe.parcel", []byte(`{"Type":"*parcel_test.balanceWeapon","Obj":{"Name":"axe","Damage":20}}`))
+	base.WriteFile("stick.parcel", []byte(`{"Type":"*parcel_test.balanceWeapon","Obj":{"Name":"stick","Damage":0}}`))
+	base.WriteFile("other.parcel", []byte(`{"Type":"*parcel_test.testType","Obj":{"String":"x"}}`))
+	// the mod layer shadows the sword
+	mods.WriteFile("sword.parcel", []byte(`{"Type":"*parcel_test.balanceWeapon","Obj":{"Name":"sword","Damage":30}}`))
+	return p, base, mods
+}
+
+func scale(path string, w *balanceWeapon) (bool, error) {
+	if w.Damage == 0 {
+		return false, nil
+	}
+	w.Damage *= 1.1
+	return true, nil
+}
+
+func TestForEach(t *testing.T) {
+	p, _, mods := setupForEach(t)
+
+	report, err := parcel.ForEach(scale, parcel.ForEachOptions{DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"axe.parcel", "stick.parcel", "sword.parcel"}, report.Visited)
+	assert.Equal(t, []string{"axe.parcel", "sword.parcel"}, report.Modified)
+	assert.Empty(t, report.Written)
+	assert.Equal(t, "3 visited, 2 modified (dry run)", report.String())
+	_, err = mods.ReadFile("axe.parcel")
+	assert.Error(t, err, "a dry run writes nothing")
+
+	sword, err := parcel.Load[balanceWeapon]("sword")
+	assert.NoError(t, err)
+	report, err = parcel.ForEach(scale, parcel.ForEachOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "3 visited, 2 modified, 2 written", report.String())
+	assert.InDelta(t, 33.0, sword.Damage, 1e-9, "loaded objects are edited in place")
+	assert.False(t, p.IsDirty(sword))
+
+	p2 := parcel.NewParcel()
+	p2.RegisterFS(mods, 0)
+	p2.AddType(&balanceWeapon{})
+	axe, err := p2.Load(&balanceWeapon{}, "axe")
+	assert.NoError(t, err)
+	assert.InDelta(t, 22.0, axe.(*balanceWeapon).Damage, 1e-9, "assets from lower layers are saved to the writable layer")
+}
+
+func TestForEachErrors(t *testing.T) {
+	p, base, _ := setupForEach(t)
+	base.WriteFile("broken.parcel", []byte(`{"Type":`))
+	base.WriteFile("bad.parcel", []byte(`{"Type":"*parcel_test.balanceWeapon","Obj":{"Damage":"high"}}`))
+	fail := errors.New("no")
+	fn := func(path string, obj any) (bool, error) {
+		if path == "axe.parcel" {
+			return false, fail
+		}
+		return true, nil
+	}
+
+	report, err := p.ForEach(&balanceWeapon{}, fn, parcel.ForEachOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, report.Failed, "axe.parcel")
+	assert.Equal(t, []string{"axe.parcel"}, report.Visited, "the pass stops at the first failure")
+
+	report, err = p.ForEach(&balanceWeapon{}, fn, parcel.ForEachOptions{ContinueOnError: true})
+	assert.ErrorIs(t, err, fail)
+	assert.Contains(t, report.Failed, "bad.parcel")
+	assert.Contains(t, report.Failed, "axe.parcel")
+	assert.Equal(t, []string{"stick.parcel", "sword.parcel"}, report.Modified)
+	assert.Equal(t, "3 visited, 2 modified, 2 written, 2 failed, 1 unreadable", report.String())
+
+	_, err = p.ForEach(&struct{}{}, fn, parcel.ForEachOptions{})
+	assert.ErrorIs(t, err, parcel.ErrUnknownType)
+}
+
+func TestForEachUnreadableHeaders(t *testing.T) {
+	p, base, _ := setupForEach(t)
+	base.WriteFile("broken.parcel", []byte(`{"Type":`))
+
+	report, err := p.ForEach(&balanceWeapon{}, func(path string, obj any) (bool, error) {
+		return false, nil
+	}, parcel.ForEachOptions{})
+	assert.NoError(t, err, "an unreadable asset does not stop the pass")
+	assert.Contains(t, report.Unreadable, "broken.parcel")
+	assert.Empty(t, report.Failed)
+	assert.Len(t, report.Visited, 3)
+}
+
+func TestForEachErrorUndoesEdits(t *testing.T) {
+	p, _, _ := setupForEach(t)
+	axe, err := p.Load(&balanceWeapon{}, "axe")
+	assert.NoError(t, err)
+
+	_, err = p.ForEach(&balanceWeapon{}, func(path string, obj any) (bool, error) {
+		obj.(*balanceWeapon).Damage = 99
+		return true, errors.New("half done")
+	}, parcel.ForEachOptions{ContinueOnError: true})
+	assert.Error(t, err)
+	assert.Equal(t, 20.0, axe.(*balanceWeapon).Damage, "the loaded object is restored")
+	assert.False(t, p.IsDirty(axe))
+}
@@ -0,0 +1,37 @@
+package parcel
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// FormatOptions controls the layout of saved files.  Regardless of the
+// options, map keys are always written in sorted order so that saving
+// an unchanged object produces identical bytes.
+type FormatOptions struct {
+	// Indent pretty prints saved files, using Indent for each level
+	// of nesting.  An empty Indent writes compact files.
+	Indent string
+}
+
+func (p *Parcel) SetFormatOptions(opts FormatOptions) {
+	p.format = opts
+}
+
+func (p *Parcel) GetFormatOptions() FormatOptions {
+	return p.format
+}
+
+// formatData lays out serialized data according to the Parcel's
+// FormatOptions.
+func (p *Parcel) formatData(data []byte) ([]byte, error) {
+	if p.format.Indent == "" {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", p.format.Indent); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,124 @@
+package parcel_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+type formatTest struct {
+	Name  string
+	Map   map[string]int
+	IntTo map[int]string
+	Big   int64
+	Small int64
+	UBig  uint64
+	F32   float32
+}
+
+func TestDeterministicOutput(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&formatTest{})
+
+	obj := &formatTest{Map: map[string]int{}, IntTo: map[int]string{}}
+	for i := range 50 {
+		obj.Map[fmt.Sprint("key", i)] = i
+		obj.IntTo[i] = fmt.Sprint(i)
+	}
+	assert.NoError(t, p.SetSavePath(obj, "format"))
+	first, _ := store.ReadFile("format.parcel")
+	for range 10 {
+		assert.NoError(t, p.Save(obj))
+		again, _ := store.ReadFile("format.parcel")
+		assert.Equal(t, string(first), string(again))
+	}
+}
+
+func TestIndentedOutput(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&formatTest{})
+	p.SetFormatOptions(parcel.FormatOptions{Indent: "  "})
+
+	obj := &formatTest{Name: "pretty", Map: map[string]int{"b": 2, "a": 1}}
+	assert.NoError(t, p.SetSavePath(obj, "pretty"))
+	data, _ := store.ReadFile("pretty.parcel")
+	assert.Equal(t, `{
+  "Type": "*parcel_test.formatTest",
+  "Parent": "",
+  "Obj": {
+    "Name": "pretty",
+    "Map": {
+      "a": 1,
+      "b": 2
+    },
+    "IntTo": {},
+    "Big": 0,
+    "Small": 0,
+    "UBig": 0,
+    "F32": 0
+  }
+}
+`, string(data))
+}
+
+func TestIntegerPrecision(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&formatTest{})
+
+	obj := &formatTest{
+		Map:   map[string]int{},
+		IntTo: map[int]string{},
+		Big:   math.MinInt64,
+		Small: -42,
+		UBig:  math.MaxUint64,
+		F32:   0.1,
+	}
+	assert.NoError(t, p.SetSavePath(obj, "ints"))
+
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	p2.AddType(&formatTest{})
+	loaded, err := p2.Load(&formatTest{}, "ints")
+	assert.NoError(t, err)
+	assert.Equal(t, obj, loaded)
+
+	data, _ := store.ReadFile("ints.parcel")
+	assert.Contains(t, string(data), `"Small":-42`)
+	assert.Contains(t, string(data), `"F32":0.1`)
+}
+
+func TestIntegerDecodeErrors(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&formatTest{})
+	p.AddType(&basicTypes{})
+
+	for name, obj := range map[string]string{
+		"bool":     `{"Small":true}`,
+		"null":     `{"Small":null}`,
+		"object":   `{"Small":{}}`,
+		"array":    `{"UBig":[1]}`,
+		"fraction": `{"Small":1.5}`,
+		"negative": `{"UBig":-1}`,
+		"string":   `{"Small":"ten"}`,
+	} {
+		store.WriteFile(name+".parcel", []byte(`{"Type":"*parcel_test.formatTest","Obj":`+obj+`}`))
+		_, err := p.Load(&formatTest{}, name)
+		assert.Error(t, err, name)
+	}
+
+	store.WriteFile("narrow.parcel", []byte(`{"Type":"*parcel_test.basicTypes","Obj":{"Int32":4294967296}}`))
+	_, err := p.Load(&basicTypes{}, "narrow")
+	assert.ErrorIs(t, err, parcel.ErrInvalidType)
+
+	store.WriteFile("ok.parcel", []byte(`{"Type":"*parcel_test.formatTest","Obj":{"Small":-3,"UBig":"7","Big":2.0}}`))
+	loaded, err := p.Load(&formatTest{}, "ok")
+	assert.NoError(t, err)
+	assert.Equal(t, &formatTest{Small: -3, UBig: 7, Big: 2}, loaded)
+}
@@ -0,0 +1,22 @@
+package parcel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Hash returns a stable digest of the canonical serialized form of obj.
+// The digest ignores FormatOptions, so it only changes when the saved
+// content of obj changes.
+func (p *Parcel) Hash(obj any) (string, error) {
+	data, err := p.canonicalData(obj)
+	if err != nil {
+		return "", err
+	}
+	return hashData(data), nil
+}
+
+func hashData(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
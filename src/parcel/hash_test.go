@@ -0,0 +1,63 @@
+package parcel_test
+
+import (
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingFS struct {
+	*parcel.MemoryFS
+	writes int
+}
+
+func (c *countingFS) WriteFile(path string, data []byte) error {
+	c.writes++
+	return c.MemoryFS.WriteFile(path, data)
+}
+
+func TestHash(t *testing.T) {
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{})
+
+	a := &testType{String: "a", Float: 1}
+	h1, err := p.Hash(a)
+	assert.NoError(t, err)
+	assert.Len(t, h1, 64)
+
+	h2, _ := p.Hash(&testType{String: "a", Float: 1})
+	assert.Equal(t, h1, h2, "equal content hashes the same")
+
+	p.SetFormatOptions(parcel.FormatOptions{Indent: "\t"})
+	h3, _ := p.Hash(a)
+	assert.Equal(t, h1, h3, "format options do not change the hash")
+
+	a.Float = 2
+	h4, _ := p.Hash(a)
+	assert.NotEqual(t, h1, h4)
+}
+
+func TestSaveSkipsUnchanged(t *testing.T) {
+	p := parcel.NewParcel()
+	store := &countingFS{MemoryFS: parcel.NewMemoryFS()}
+	p.RegisterFS(store, 0)
+	p.RegisterWriteableFS(store)
+	p.AddType(&testType{})
+
+	obj := &testType{String: "skip"}
+	assert.NoError(t, p.SetSavePath(obj, "skip"))
+	assert.Equal(t, 1, store.writes)
+
+	changed, err := p.SaveChanged(obj)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.NoError(t, p.Save(obj))
+	assert.Equal(t, 1, store.writes, "unchanged saves do not write")
+
+	obj.String = "changed"
+	changed, err = p.SaveChanged(obj)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 2, store.writes)
+}
@@ -0,0 +1,167 @@
+package parcel
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// ChangeListener is called after Undo or Redo has restored obj in place.
+type ChangeListener func(obj any)
+
+type history struct {
+	limit int
+	undo  []historyEntry
+	redo  []historyEntry
+	// serialized form of each object as of its last Load or Save
+	baseline map[any][]byte
+	// objects that have had RecordChange called since their last Save
+	recorded map[any]bool
+}
+
+type historyEntry struct {
+	obj   any
+	state []byte
+}
+
+// EnableHistory starts recording an undo history.  A snapshot of an
+// object is taken before each Save and on every call to RecordChange.
+// At most limit undo steps are kept, a limit of 0 means no limit.
+// Calling EnableHistory again clears any existing history.
+func (p *Parcel) EnableHistory(limit int) {
+	p.history = &history{
+		limit:    limit,
+		baseline: make(map[any][]byte),
+		recorded: make(map[any]bool),
+	}
+}
+
+// AddChangeListener registers fn to be called whenever Undo or Redo
+// modifies an object.
+func (p *Parcel) AddChangeListener(fn ChangeListener) {
+	p.listeners = append(p.listeners, fn)
+}
+
+// RecordChange snapshots obj so that the changes about to be made to
+// it can be undone.  Call it before modifying obj.
+func (p *Parcel) RecordChange(obj any) error {
+	if p.history == nil {
+		return fmt.Errorf("history is not enabled.  Call EnableHistory first")
+	}
+	state, err := p.jsonSave(obj)
+	if err != nil {
+		return err
+	}
+	p.history.push(obj, state)
+	p.history.recorded[obj] = true
+	return nil
+}
+
+func (p *Parcel) CanUndo() bool {
+	return p.history != nil && len(p.history.undo) > 0
+}
+
+func (p *Parcel) CanRedo() bool {
+	return p.history != nil && len(p.history.redo) > 0
+}
+
+// Undo restores the most recently recorded object to its previous
+// state and returns it.  If there is nothing to undo, nil is returned.
+func (p *Parcel) Undo() (any, error) {
+	if !p.CanUndo() {
+		return nil, nil
+	}
+	return p.step(&p.history.undo, &p.history.redo)
+}
+
+// Redo reapplies the most recently undone change and returns the
+// object that was modified.  If there is nothing to redo, nil is returned.
+func (p *Parcel) Redo() (any, error) {
+	if !p.CanRedo() {
+		return nil, nil
+	}
+	return p.step(&p.history.redo, &p.history.undo)
+}
+
+// step restores the last entry of from and moves it to to.  The entry
+// is only removed once it has been restored, so a failed step can be
+// tried again.
+func (p *Parcel) step(from *[]historyEntry, to *[]historyEntry) (any, error) {
+	entry := (*from)[len(*from)-1]
+	current, err := p.jsonSave(entry.obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.restore(entry.obj, entry.state); err != nil {
+		return nil, err
+	}
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, historyEntry{obj: entry.obj, state: current})
+	p.notifyChanged(entry.obj)
+	return entry.obj, nil
+}
+
+// historyLoaded remembers the state of a freshly loaded object so that
+// the first Save can be undone.
+func (p *Parcel) historyLoaded(obj any) error {
+	if p.history == nil {
+		return nil
+	}
+	state, err := p.jsonSave(obj)
+	if err != nil {
+		return err
+	}
+	p.history.baseline[obj] = state
+	return nil
+}
+
+// historySaved records an undo step for obj unless RecordChange has
+// already captured one since the last Save.
+func (p *Parcel) historySaved(obj any) error {
+	if p.history == nil {
+		return nil
+	}
+	h := p.history
+	state, err := p.jsonSave(obj)
+	if err != nil {
+		return err
+	}
+	before, known := h.baseline[obj]
+	if known && !h.recorded[obj] && !bytes.Equal(before, state) {
+		h.push(obj, before)
+	}
+	h.baseline[obj] = state
+	delete(h.recorded, obj)
+	return nil
+}
+
+func (h *history) push(obj any, state []byte) {
+	h.undo = append(h.undo, historyEntry{obj: obj, state: state})
+	if h.limit > 0 && len(h.undo) > h.limit {
+		h.undo = h.undo[len(h.undo)-h.limit:]
+	}
+	h.redo = nil
+}
+
+// restore overwrites obj in place with a previously saved state.
+func (p *Parcel) restore(obj any, state []byte) error {
+	v := reflect.ValueOf(obj)
+	fresh, err := p.newFromType(v.Type())
+	if err != nil {
+		fresh = reflect.New(v.Type().Elem()).Interface()
+	}
+	if err := p.jsonLoad(fresh, state); err != nil {
+		return err
+	}
+	v.Elem().Set(reflect.ValueOf(fresh).Elem())
+	if postloader, ok := obj.(PostLoader); ok {
+		postloader.PostLoad()
+	}
+	return nil
+}
+
+func (p *Parcel) notifyChanged(obj any) {
+	for _, fn := range p.listeners {
+		fn(obj)
+	}
+}
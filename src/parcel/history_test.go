@@ -0,0 +1,106 @@
+package parcel_test
+
+import (
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndoRedoSave(t *testing.T) {
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{})
+	p.EnableHistory(0)
+
+	var changed []any
+	p.AddChangeListener(func(obj any) { changed = append(changed, obj) })
+
+	obj := &testType{String: "first"}
+	assert.NoError(t, p.SetSavePath(obj, "undo"))
+	assert.False(t, p.CanUndo(), "nothing to undo before the first change")
+
+	obj.String = "second"
+	obj.Float = 2
+	assert.NoError(t, p.Save(obj))
+	assert.True(t, p.CanUndo())
+
+	undone, err := p.Undo()
+	assert.NoError(t, err)
+	assert.True(t, undone == obj, "objects are restored in place")
+	assert.Equal(t, "first", obj.String)
+	assert.Equal(t, float32(0), obj.Float)
+	assert.True(t, obj.postLoad)
+	assert.False(t, p.CanUndo())
+
+	redone, err := p.Redo()
+	assert.NoError(t, err)
+	assert.True(t, redone == obj)
+	assert.Equal(t, "second", obj.String)
+	assert.Equal(t, float32(2), obj.Float)
+	assert.Equal(t, []any{obj, obj}, changed)
+
+	redone, err = p.Redo()
+	assert.NoError(t, err)
+	assert.Nil(t, redone, "nothing to redo")
+}
+
+func TestUndoRecordChange(t *testing.T) {
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{})
+	assert.Error(t, p.RecordChange(&testType{}), "history must be enabled")
+	p.EnableHistory(2)
+
+	linked := &testType{String: "linked"}
+	assert.NoError(t, p.SetSavePath(linked, "linked"))
+	obj := &testType{String: "v0"}
+	assert.NoError(t, p.SetSavePath(obj, "obj"))
+
+	for _, s := range []string{"v1", "v2", "v3"} {
+		p.RecordChange(obj)
+		obj.String = s
+		obj.OtherObj = linked
+	}
+	assert.NoError(t, p.Save(obj))
+
+	p.Undo()
+	assert.Equal(t, "v2", obj.String)
+	assert.True(t, obj.OtherObj == linked, "references are restored as references")
+	p.Undo()
+	assert.Equal(t, "v1", obj.String)
+	assert.False(t, p.CanUndo(), "history is limited")
+
+	p.RecordChange(obj)
+	obj.String = "branch"
+	assert.False(t, p.CanRedo(), "recording clears the redo stack")
+}
+
+func TestUndoFailureKeepsHistory(t *testing.T) {
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{})
+	p.EnableHistory(0)
+
+	linked := &testType{String: "linked"}
+	assert.NoError(t, p.SetSavePath(linked, "linked"))
+	obj := &testType{String: "v0", OtherObj: linked}
+	assert.NoError(t, p.SetSavePath(obj, "obj"))
+	assert.NoError(t, p.RecordChange(obj))
+	obj.String = "v1"
+	obj.OtherObj = nil
+
+	// the recorded state refers to an asset that is gone
+	assert.NoError(t, p.Delete("linked"))
+	_, err := p.Undo()
+	assert.Error(t, err)
+	assert.True(t, p.CanUndo(), "a failed undo keeps its step")
+	assert.False(t, p.CanRedo())
+	assert.Equal(t, "v1", obj.String)
+
+	assert.NoError(t, p.SetSavePath(&testType{String: "again"}, "linked"))
+	undone, err := p.Undo()
+	assert.NoError(t, err)
+	assert.True(t, undone == obj)
+	assert.Equal(t, "v0", obj.String)
+	assert.Equal(t, "again", obj.OtherObj.String)
+	assert.False(t, p.CanUndo())
+	assert.True(t, p.CanRedo())
+}
@@ -0,0 +1,178 @@
+package parcel
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+)
+
+/*
+The identity maps tie each loaded or saved object to its path.  By
+default they hold strong references, so an object stays in memory
+until it is unloaded.  In weak mode objectFromPath holds weakRefs and
+pathFromObject is keyed by the object's address, so nothing in the
+Parcel keeps an asset alive.  Because an address may be reused once an
+object is collected, a path found by address is only trusted if the
+weakRef stored for that path still refers to the same object.
+*/
+
+// weakRef refers to an object without keeping it alive.
+type weakRef interface {
+	// value returns the object, or nil once it has been collected.
+	value() any
+}
+
+// objectKey identifies an object by address in weak mode.
+type objectKey struct {
+	typ  reflect.Type
+	addr uintptr
+}
+
+// EnableWeakCache switches the identity maps to weak references.  Any
+// asset that is no longer referenced outside of the Parcel can then be
+// garbage collected, and a later Load reads it from disk again.
+// Weak references need Go 1.24 or newer.  Assets must be heap allocated,
+// as they are when created by New or Load.  Objects recorded in the undo
+// history are still kept alive by the history.
+func (p *Parcel) EnableWeakCache() error {
+	if !weakCacheSupported {
+		return fmt.Errorf("weak references are not supported by this version of Go")
+	}
+	if p.weakCache {
+		return nil
+	}
+	loaded := map[string]any{}
+	for path, obj := range p.loadedObjects() {
+		loaded[path] = obj
+	}
+	p.weakCache = true
+	p.objectFromPath = make(map[string]any)
+	p.pathFromObject = make(map[any]string)
+	for path, obj := range loaded {
+		p.register(path, obj)
+	}
+	return nil
+}
+
+// Unload forgets the asset at path.  The object itself is not changed,
+// but the next Load of path reads it from disk again and saving the old
+// object requires a new call to SetSavePath.
+func (p *Parcel) Unload(path string) {
+	path = p.normPath(path)
+	if obj, loaded := p.objectAt(path); loaded && p.history != nil {
+		delete(p.history.baseline, obj)
+		delete(p.history.recorded, obj)
+	}
+	p.forget(path)
+}
+
+// Collect removes the bookkeeping for assets that have been garbage
+// collected in weak mode, and returns how many were removed.  Collected
+// assets are also removed lazily as their paths are used.
+func (p *Parcel) Collect() int {
+	removed := 0
+	for path, entry := range p.objectFromPath {
+		if ref, isRef := entry.(weakRef); isRef && ref.value() == nil {
+			p.forget(path)
+			removed++
+		}
+	}
+	for key, path := range p.pathFromObject {
+		if _, loaded := p.objectFromPath[path]; !loaded {
+			delete(p.pathFromObject, key)
+		}
+	}
+	return removed
+}
+
+// register ties obj and path together in both directions.
+func (p *Parcel) register(path string, obj any) {
+	if p.weakCache {
+		if ref, ok := makeWeakRef(obj); ok {
+			p.objectFromPath[path] = ref
+			p.pathFromObject[p.identityKey(obj)] = path
+			return
+		}
+	}
+	p.objectFromPath[path] = obj
+	p.pathFromObject[p.identityKey(obj)] = path
+}
+
+// unregister removes path and the object loaded at it.
+func (p *Parcel) unregister(path string) {
+	entry, exists := p.objectFromPath[path]
+	if !exists {
+		return
+	}
+	delete(p.objectFromPath, path)
+	obj := entry
+	if ref, isRef := entry.(weakRef); isRef {
+		obj = ref.value()
+	}
+	if obj != nil {
+		key := p.identityKey(obj)
+		if p.pathFromObject[key] == path {
+			delete(p.pathFromObject, key)
+		}
+	}
+}
+
+// forget removes path and everything remembered about the file at it.
+func (p *Parcel) forget(path string) {
+	p.unregister(path)
+	delete(p.cleanHash, path)
+	delete(p.extras, path)
+	p.forgetCodecState(path)
+}
+
+// objectAt returns the object loaded at path.
+func (p *Parcel) objectAt(path string) (any, bool) {
+	entry, exists := p.objectFromPath[path]
+	if !exists {
+		return nil, false
+	}
+	if ref, isRef := entry.(weakRef); isRef {
+		obj := ref.value()
+		if obj == nil {
+			p.forget(path)
+			return nil, false
+		}
+		return obj, true
+	}
+	return entry, true
+}
+
+// pathOf returns the path that obj is saved at.
+func (p *Parcel) pathOf(obj any) (string, bool) {
+	path, exists := p.pathFromObject[p.identityKey(obj)]
+	if !exists || !p.weakCache {
+		return path, exists
+	}
+	if current, loaded := p.objectAt(path); !loaded || current != obj {
+		return "", false
+	}
+	return path, true
+}
+
+// loadedObjects iterates over every loaded asset.
+func (p *Parcel) loadedObjects() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for path := range p.objectFromPath {
+			obj, loaded := p.objectAt(path)
+			if loaded && !yield(path, obj) {
+				return
+			}
+		}
+	}
+}
+
+func (p *Parcel) identityKey(obj any) any {
+	if !p.weakCache {
+		return obj
+	}
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer {
+		return obj
+	}
+	return objectKey{typ: v.Type(), addr: v.Pointer()}
+}
@@ -0,0 +1,9 @@
+//go:build !go1.24
+
+package parcel
+
+const weakCacheSupported = false
+
+func makeWeakRef(obj any) (weakRef, bool) {
+	return nil, false
+}
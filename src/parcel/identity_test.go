@@ -0,0 +1,87 @@
+package parcel_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnload(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	obj := &testType{String: "saved"}
+	assert.NoError(t, p.SetSavePath(obj, "unload"))
+
+	obj.String = "unsaved"
+	p.Unload("unload")
+	assert.Error(t, p.Save(obj), "unloaded objects have no save path")
+
+	loaded, err := p.Load(&testType{}, "unload")
+	assert.NoError(t, err)
+	assert.True(t, loaded != obj, "Load reads unloaded assets from disk")
+	assert.Equal(t, "saved", loaded.(*testType).String)
+
+	// other parcels sharing the store are unaffected
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	p2.Unload("missing")
+}
+
+func TestWeakCache(t *testing.T) {
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{})
+	if err := p.EnableWeakCache(); err != nil {
+		t.Skip(err)
+	}
+
+	obj := &testType{String: "saved"}
+	assert.NoError(t, p.SetSavePath(obj, "weak"))
+	held, err := p.Load(&testType{}, "weak")
+	assert.NoError(t, err)
+	assert.True(t, held == obj, "live objects are still cached")
+	assert.False(t, p.IsDirty(obj))
+
+	// change the object without saving, then drop it
+	obj.String = "unsaved"
+	obj, held = nil, nil
+	for range 3 {
+		runtime.GC()
+	}
+	p.Collect()
+
+	loaded, err := p.Load(&testType{}, "weak")
+	assert.NoError(t, err)
+	assert.Equal(t, "saved", loaded.(*testType).String, "collected assets are read from disk again")
+	assert.Empty(t, p.DirtyAssets())
+
+	other := &testType{String: "other"}
+	assert.NoError(t, p.SetSavePath(other, "other"))
+	loaded.(*testType).OtherObj = other
+	assert.NoError(t, p.Save(loaded))
+	runtime.KeepAlive(other)
+}
+
+func TestWeakCacheForgetsCollectedExtras(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	if err := p.EnableWeakCache(); err != nil {
+		t.Skip(err)
+	}
+	p.AddType(&extrasAsset{})
+	store.WriteFile("old.parcel", []byte(`{"Type":"*parcel_test.extrasAsset","Parent":"","Obj":{"Name":"old","Secret":"stale"}}`))
+	obj, err := p.Load(&extrasAsset{}, "old")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", obj.(*extrasAsset).Name)
+
+	obj = nil
+	for range 3 {
+		runtime.GC()
+	}
+
+	// no Collect, the dead entry is removed when the path is next used
+	assert.NoError(t, p.SetSavePath(&extrasAsset{Name: "new"}, "old"))
+	data, _ := store.ReadFile("old.parcel")
+	assert.NotContains(t, string(data), "Secret")
+}
@@ -0,0 +1,35 @@
+//go:build go1.24
+
+package parcel
+
+import (
+	"reflect"
+	"unsafe"
+	"weak"
+)
+
+const weakCacheSupported = true
+
+type weakPointer struct {
+	typ reflect.Type
+	ptr weak.Pointer[byte]
+}
+
+// makeWeakRef returns a weakRef to obj, or false if obj cannot be
+// weakly referenced.  Zero sized values are not heap allocated, so they
+// are always held strongly.
+func makeWeakRef(obj any) (weakRef, bool) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Type().Elem().Size() == 0 {
+		return nil, false
+	}
+	return weakPointer{typ: v.Type(), ptr: weak.Make((*byte)(v.UnsafePointer()))}, true
+}
+
+func (w weakPointer) value() any {
+	b := w.ptr.Value()
+	if b == nil {
+		return nil
+	}
+	return reflect.NewAt(w.typ.Elem(), unsafe.Pointer(b)).Interface()
+}
@@ -6,21 +6,38 @@ Exported fields are saved as normal.
 Pointer fields are saved as either
 1. A string to an object path if the pointer is to a known object OR
 2. The normal save structure of the object
+Interface fields holding a known object are saved as its path, the
+header of the referenced file gives the type to load.  Pointers to
+structs are saved in place and loaded into the value the field already
+holds, other values cannot be saved in interface fields.  Interfaces
+are only allowed as struct fields, not as slice, array or map elements.
+Map keys are written in sorted order so that output is byte stable.
+Integers that cannot be exactly represented as a float64 are saved as
+strings, both forms are accepted when loading.
+Fields tagged `parcel:"default=..."` that are missing from a file are
+set to the default, and files missing a field tagged `parcel:"required"`
+fail to load.  Defaults are applied after the AddFactoryForType factory
+has run, so a default replaces whatever the factory set that field to.
+Pointer and interface fields cannot have either tag, since nil ones are
+not written.
 
 */
 
 import (
 	"encoding"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/launchdarkly/go-jsonstream/v3/jreader"
 	"github.com/launchdarkly/go-jsonstream/v3/jwriter"
 )
 
-var valueZero = reflect.Value{}
-
 func (p *Parcel) jsonSave(T any) ([]byte, error) {
 	w := jwriter.NewWriter()
 	defer w.Flush()
@@ -28,240 +45,688 @@ func (p *Parcel) jsonSave(T any) ([]byte, error) {
 	return w.Bytes(), err
 }
 
+// jsonSaveAsset writes the on-disk header with Obj written in full,
+// rather than as a reference to itself.
+func (p *Parcel) jsonSaveAsset(d diskSaveFormat) ([]byte, error) {
+	w := jwriter.NewWriter()
+	defer w.Flush()
+	obj := w.Object()
+	obj.Name("Type").String(d.Type)
+	obj.Name("Parent").String(d.Parent)
+	err := p.jsonSaveWriter(obj.Name("Obj"), reflect.ValueOf(d.Obj))
+	obj.End()
+	return w.Bytes(), err
+}
+
 var customSaveLoader = reflect.TypeFor[CustomSaveLoader]()
 
+// encodeFunc and decodeFunc are compiled once per type, see typePlan.
+type (
+	encodeFunc func(p *Parcel, w *jwriter.Writer, v reflect.Value) error
+	decodeFunc func(p *Parcel, pr *preader, v reflect.Value) error
+)
+
 func (p *Parcel) jsonSaveWriter(w *jwriter.Writer, v reflect.Value) error {
-	if v.Type().Implements(customSaveLoader) {
-		toSave, err := v.Interface().(CustomSaveLoader).Save()
-		if err != nil {
-			return err
+	return planFor(v.Type()).encoder()(p, w, v)
+}
+
+// saveField writes a struct field.  Nil pointers are skipped and
+// pointers to known objects are written as their path.
+func (p *Parcel) saveField(obj *jwriter.ObjectState, name string, fv reflect.Value) error {
+	return p.saveFieldWith(obj, name, fv, planFor(fv.Type()))
+}
+
+func (p *Parcel) saveFieldWith(obj *jwriter.ObjectState, name string, fv reflect.Value, plan *typePlan) error {
+	kind := fv.Kind()
+	if kind == reflect.Pointer || kind == reflect.Interface {
+		if fv.IsNil() { // don't bother to write nil ptrs
+			return nil
+		}
+		// if it's a pointer to a known object, write the path instead
+		if path, ok := p.pathOf(fv.Interface()); ok {
+			obj.Name(name).String(path)
+			return nil
+		}
+		// strings in interface fields are paths, so only objects can be
+		// saved in place
+		if kind == reflect.Interface && !isInlineInterface(fv.Elem()) {
+			return fmt.Errorf("%w: interface field %s holds a %s, which is neither a saved asset nor a pointer to a struct",
+				ErrInvalidType, name, fv.Elem().Type())
 		}
-		return p.jsonSaveWriter(w, reflect.ValueOf(toSave))
 	}
-	switch v.Kind() {
+	return plan.encoder()(p, obj.Name(name), fv)
+}
+
+// isInlineInterface reports whether v, held by an interface field, can
+// be saved in place as an object.
+func isInlineInterface(v reflect.Value) bool {
+	return v.Kind() == reflect.Pointer && !v.IsNil() && isStruct(v.Type().Elem()) && !planFor(v.Type()).custom
+}
+
+func compileEncoder(typ reflect.Type) encodeFunc {
+	if planFor(typ).custom {
+		return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+			toSave, err := v.Interface().(CustomSaveLoader).Save()
+			if err != nil {
+				return err
+			}
+			return p.jsonSaveWriter(w, reflect.ValueOf(toSave))
+		}
+	}
+	switch typ.Kind() {
 	case reflect.Interface:
-		p.jsonSaveWriter(w, v.Elem())
+		return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+			if v.IsNil() {
+				w.Null()
+				return nil
+			}
+			return p.jsonSaveWriter(w, v.Elem())
+		}
 
 	case reflect.Pointer:
-		p.jsonSaveWriter(w, v.Elem())
+		elem := planFor(typ.Elem())
+		return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+			if v.IsNil() {
+				w.Null()
+				return nil
+			}
+			return elem.encoder()(p, w, v.Elem())
+		}
 
 	case reflect.Bool:
-		w.Bool(v.Bool())
+		return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+			w.Bool(v.Bool())
+			return nil
+		}
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		w.Int(int(v.Int()))
+		return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+			writeInt(w, v.Int())
+			return nil
+		}
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		w.Int(int(v.Uint()))
+		return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+			writeUint(w, v.Uint())
+			return nil
+		}
 
-	case reflect.Float32, reflect.Float64:
-		w.Float64(v.Float())
+	case reflect.Float32:
+		return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+			// write the shortest form that round trips as a float32
+			f, _ := strconv.ParseFloat(strconv.FormatFloat(v.Float(), 'g', -1, 32), 64)
+			w.Float64(f)
+			return nil
+		}
+
+	case reflect.Float64:
+		return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+			w.Float64(v.Float())
+			return nil
+		}
 
 	case reflect.String:
-		w.String(v.String())
+		return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+			w.String(v.String())
+			return nil
+		}
 
 	case reflect.Struct:
-		obj := w.Object()
-		for _, field := range reflect.VisibleFields(v.Type()) {
-			if !field.IsExported() {
-				continue
-			}
-			fv := v.FieldByIndex(field.Index)
-			if fv.Kind() == reflect.Pointer {
-				if fv.IsNil() { // don't bother to write nil ptrs
-					continue
-				}
-				// if it's a pointer to a known object, write the path instead
-				if path, ok := p.pathFromObject[fv.Interface()]; ok {
-					fv = reflect.ValueOf(path)
+		if reflect.PointerTo(typ).Implements(parcelSaver) {
+			return compileGeneratedEncoder(typ)
+		}
+		fields := planFor(typ).fields
+		plans := make([]*typePlan, len(fields))
+		for i, field := range fields {
+			plans[i] = planFor(field.typ)
+		}
+		return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+			obj := w.Object()
+			for i, field := range fields {
+				fv := v.FieldByIndex(field.index)
+				if err := p.saveFieldWith(&obj, field.name, fv, plans[i]); err != nil {
+					return err
 				}
 			}
-
-			propWriter := obj.Name(field.Name)
-			err := p.jsonSaveWriter(propWriter, fv)
-			if err != nil {
-				return err
-			}
+			obj.End()
+			return nil
 		}
-		obj.End()
 
 	case reflect.Map:
-		obj := w.Object()
-		itr := v.MapRange()
-		for itr.Next() {
-			k, err := resolveKeyName(itr.Key())
-			if err != nil {
-				return err
+		elem := planFor(typ.Elem())
+		type entry struct {
+			name  string
+			value reflect.Value
+		}
+		return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+			entries := make([]entry, 0, v.Len())
+			itr := v.MapRange()
+			for itr.Next() {
+				k, err := p.resolveKeyName(itr.Key())
+				if err != nil {
+					return err
+				}
+				entries = append(entries, entry{k, itr.Value()})
 			}
-			v := itr.Value()
-			propWriter := obj.Name(k)
-			err = p.jsonSaveWriter(propWriter, v)
-			if err != nil {
-				return err
+			slices.SortFunc(entries, func(a, b entry) int {
+				return strings.Compare(a.name, b.name)
+			})
+
+			obj := w.Object()
+			for _, e := range entries {
+				err := elem.encoder()(p, obj.Name(e.name), e.value)
+				if err != nil {
+					return err
+				}
 			}
+			obj.End()
+			return nil
 		}
-		obj.End()
 
 	case reflect.Slice, reflect.Array:
 		// special case byte arrays
-		if v.Type().Elem() == reflect.TypeFor[byte]() {
-			w.String(base64.RawStdEncoding.EncodeToString(v.Bytes()))
-			return nil
+		if typ.Elem() == reflect.TypeFor[byte]() {
+			return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+				w.String(base64.RawStdEncoding.EncodeToString(v.Bytes()))
+				return nil
+			}
 		}
-
-		obj := w.Array()
-		for i := 0; i < v.Len(); i++ {
-			elemW := jwriter.NewWriter()
-			err := p.jsonSaveWriter(&elemW, v.Index(i))
-			if err != nil {
-				return err
+		elem := planFor(typ.Elem())
+		return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+			arr := w.Array()
+			for i := 0; i < v.Len(); i++ {
+				// values written to w while the array is open become its elements
+				err := elem.encoder()(p, w, v.Index(i))
+				if err != nil {
+					return err
+				}
 			}
-			obj.Raw(elemW.Bytes())
+			arr.End()
+			return nil
 		}
-		obj.End()
 	}
-	return nil
+	return func(p *Parcel, w *jwriter.Writer, v reflect.Value) error {
+		return nil
+	}
 }
 
 type preader struct {
 	r            *jreader.Reader
 	lastAny      jreader.AnyValue
 	anyWasCalled bool
+	// set when a field without a matching struct field is skipped
+	unknown bool
+}
+
+// object starts reading an object, which may already have been read
+// with Any while checking for a null or a reference.
+func (pr *preader) object() jreader.ObjectState {
+	if pr.anyWasCalled {
+		pr.anyWasCalled = false
+		if pr.lastAny.Kind != jreader.ObjectValue {
+			pr.r.AddError(fmt.Errorf("expected object, got %s", pr.lastAny.Kind))
+		}
+		return pr.lastAny.Object
+	}
+	return pr.r.Object()
+}
+
+// array is object for arrays.
+func (pr *preader) array() jreader.ArrayState {
+	if pr.anyWasCalled {
+		pr.anyWasCalled = false
+		if pr.lastAny.Kind != jreader.ArrayValue {
+			pr.r.AddError(fmt.Errorf("expected array, got %s", pr.lastAny.Kind))
+		}
+		return pr.lastAny.Array
+	}
+	return pr.r.Array()
+}
+
+// replay calls decode to read the value a, which has already been read
+// with Any.  Objects and arrays are continued from pr, other values are
+// written out again and read from a reader of their own.
+func (pr *preader) replay(a jreader.AnyValue, decode func(pr *preader) error) error {
+	if a.Kind == jreader.ObjectValue || a.Kind == jreader.ArrayValue {
+		pr.lastAny = a
+		pr.anyWasCalled = true
+		return decode(pr)
+	}
+	if err := pr.r.Error(); err != nil {
+		return err
+	}
+	w := jwriter.NewWriter()
+	switch a.Kind {
+	case jreader.BoolValue:
+		w.Bool(a.Bool)
+	case jreader.NumberValue:
+		w.Float64(a.Number)
+	case jreader.StringValue:
+		w.String(a.String)
+	}
+	r := jreader.NewReader(w.Bytes())
+	if err := decode(&preader{r: &r}); err != nil {
+		return err
+	}
+	return r.Error()
 }
 
 func (p *Parcel) jsonLoad(T any, data []byte) error {
+	_, err := p.jsonLoadTracked(T, data)
+	return err
+}
+
+// jsonLoadTracked is jsonLoad that also reports whether data held
+// fields that are not in T.
+func (p *Parcel) jsonLoadTracked(T any, data []byte) (unknown bool, err error) {
 	r := jreader.NewReader(data)
 	pr := &preader{
 		r: &r,
 	}
-	return p.jsonLoadReader(pr, reflect.ValueOf(T))
+	err = p.jsonLoadReader(pr, reflect.ValueOf(T))
+	if err == nil {
+		err = r.Error()
+	}
+	return pr.unknown, err
 }
 
 func (p *Parcel) jsonLoadReader(pr *preader, v reflect.Value) error {
-	r := pr.r
-	if v.Type().Implements(customSaveLoader) {
-		csl := v.Interface().(CustomSaveLoader)
-		return csl.Load(func(a any) error {
-			return p.jsonLoadReader(pr, reflect.ValueOf(a))
-		})
+	return planFor(v.Type()).decoder()(p, pr, v)
+}
+
+// loadField reads a struct field, the counterpart of saveField.
+func (p *Parcel) loadField(pr *preader, fv reflect.Value) error {
+	return p.loadFieldWith(pr, fv, planFor(fv.Type()))
+}
+
+func (p *Parcel) loadFieldWith(pr *preader, fv reflect.Value, plan *typePlan) error {
+	if fv.Kind() == reflect.Interface {
+		return p.loadInterface(pr, fv)
+	}
+	return plan.decoder()(p, pr, fv)
+}
+
+func compileDecoder(typ reflect.Type) decodeFunc {
+	if planFor(typ).custom {
+		return func(p *Parcel, pr *preader, v reflect.Value) error {
+			csl := v.Interface().(CustomSaveLoader)
+			return csl.Load(func(a any) error {
+				return p.jsonLoadReader(pr, reflect.ValueOf(a))
+			})
+		}
 	}
-	switch v.Kind() {
+	switch typ.Kind() {
 	case reflect.Pointer:
-		if v.IsNil() {
-			_, knownType := p.objectNewFunc[v.Type()]
-			if knownType {
-				pr.lastAny = r.Any()
-				pr.anyWasCalled = true
-
-				if pr.lastAny.Kind == jreader.StringValue {
-					t := reflect.New(v.Type())
-					loaded, err := p.Load(t.Elem().Interface(), pr.lastAny.String)
-					v.Set(reflect.ValueOf(loaded))
+		elem := planFor(typ.Elem())
+		return func(p *Parcel, pr *preader, v reflect.Value) error {
+			if !v.IsNil() {
+				return elem.decoder()(p, pr, v.Elem())
+			}
+			a := pr.r.Any()
+			if a.Kind == jreader.NullValue {
+				return pr.r.Error()
+			}
+			if _, knownType := p.objectNewFunc[typ]; knownType && a.Kind == jreader.StringValue {
+				refPath := a.String
+				end := p.trace(OpResolve, p.normPath(refPath), typ, "")
+				loaded, err := p.Load(reflect.Zero(typ).Interface(), refPath)
+				end(err)
+				if err != nil {
 					return err
 				}
+				v.Set(reflect.ValueOf(loaded))
+				return nil
 			}
-			if o, err := p.newFromType(v.Type()); err == nil {
+			if o, err := p.newFromType(typ); err == nil {
 				v.Set(reflect.ValueOf(o))
 			} else {
-				v.Set(reflect.New(v.Type().Elem()))
+				v.Set(reflect.New(typ.Elem()))
 			}
+			return pr.replay(a, func(pr *preader) error {
+				return elem.decoder()(p, pr, v.Elem())
+			})
+		}
+
+	case reflect.Bool:
+		return func(p *Parcel, pr *preader, v reflect.Value) error {
+			v.SetBool(pr.r.Bool())
+			return nil
 		}
-		return p.jsonLoadReader(pr, v.Elem())
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		v.SetInt(int64(r.Int()))
+		return func(p *Parcel, pr *preader, v reflect.Value) error {
+			n, err := readInt(pr.r)
+			if err != nil {
+				return err
+			}
+			if v.OverflowInt(n) {
+				return fmt.Errorf("%w: %d does not fit in %s", ErrInvalidType, n, v.Type())
+			}
+			v.SetInt(n)
+			return nil
+		}
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		v.SetUint(uint64(r.Int()))
+		return func(p *Parcel, pr *preader, v reflect.Value) error {
+			n, err := readUint(pr.r)
+			if err != nil {
+				return err
+			}
+			if v.OverflowUint(n) {
+				return fmt.Errorf("%w: %d does not fit in %s", ErrInvalidType, n, v.Type())
+			}
+			v.SetUint(n)
+			return nil
+		}
 
 	case reflect.Float32, reflect.Float64:
-		v.SetFloat(r.Float64())
+		return func(p *Parcel, pr *preader, v reflect.Value) error {
+			v.SetFloat(pr.r.Float64())
+			return nil
+		}
 
 	case reflect.String:
-		v.SetString(r.String())
+		return func(p *Parcel, pr *preader, v reflect.Value) error {
+			v.SetString(pr.r.String())
+			return nil
+		}
 
 	case reflect.Struct:
-		var obj jreader.ObjectState
-		if pr.anyWasCalled {
-			obj = pr.lastAny.Object
-		} else {
-			obj = r.Object()
+		if reflect.PointerTo(typ).Implements(parcelLoader) {
+			return compileGeneratedDecoder(typ)
 		}
-		pr.anyWasCalled = false
-		for obj.Next() {
-			name := string(obj.Name())
-			fieldV := v.FieldByName(name)
-			if fieldV != valueZero {
-				err := p.jsonLoadReader(pr, fieldV)
+		type fieldDecoder struct {
+			i     int
+			index []int
+			plan  *typePlan
+		}
+		structPlan := planFor(typ)
+		byName := map[string]fieldDecoder{}
+		for i, field := range structPlan.fields {
+			byName[field.name] = fieldDecoder{
+				i:     i,
+				index: field.index,
+				plan:  planFor(field.typ),
+			}
+		}
+		return func(p *Parcel, pr *preader, v reflect.Value) error {
+			var seen []bool
+			if structPlan.hasRules {
+				seen = make([]bool, len(structPlan.fields))
+			}
+			for obj := pr.object(); obj.Next(); {
+				field, ok := byName[string(obj.Name())]
+				if !ok {
+					pr.unknown = true
+					continue
+				}
+				err := p.loadFieldWith(pr, v.FieldByIndex(field.index), field.plan)
 				if err != nil {
 					return err
 				}
+				if seen != nil {
+					seen[field.i] = true
+				}
 			}
+			if seen != nil {
+				return p.applyFieldRules(structPlan, v, seen)
+			}
+			return nil
 		}
 
 	case reflect.Map:
-		if v.IsNil() {
-			// TODO - handle maps with ints & MarshalText keys
-			m := reflect.MakeMap(v.Type())
-			keyLoader := makeKeyLoader(v.Type().Key())
-			valType := v.Type().Elem()
-			for obj := r.Object(); obj.Next(); {
-				//key := reflect.ValueOf(string(obj.Name()))
+		elem := planFor(typ.Elem())
+		return func(p *Parcel, pr *preader, v reflect.Value) error {
+			// like encoding/json, entries are added to an existing map
+			m := v
+			if m.IsNil() {
+				m = reflect.MakeMap(typ)
+			}
+			keyLoader, err := p.makeKeyLoader(typ.Key())
+			if err != nil {
+				return err
+			}
+			for obj := pr.object(); obj.Next(); {
 				key, err := keyLoader(string(obj.Name()))
 				if err != nil {
 					return err
 				}
-				v := reflect.New(valType)
-				err = p.jsonLoadReader(pr, v.Elem())
+				val := reflect.New(typ.Elem()).Elem()
+				err = elem.decoder()(p, pr, val)
 				if err != nil {
 					return err
 				}
-				m.SetMapIndex(key, v.Elem())
+				m.SetMapIndex(key, val)
 			}
 			v.Set(m)
+			return nil
 		}
 
 	case reflect.Slice, reflect.Array:
-		elemTyp := v.Type().Elem()
-		if elemTyp == reflect.TypeFor[byte]() {
+		if typ.Elem() == reflect.TypeFor[byte]() {
 			// special case byte strings
-			bytes, err := base64.RawStdEncoding.DecodeString(r.String())
-			if err != nil {
-				return err
+			return func(p *Parcel, pr *preader, v reflect.Value) error {
+				bytes, err := base64.RawStdEncoding.DecodeString(pr.r.String())
+				if err != nil {
+					return err
+				}
+				if v.Kind() == reflect.Array {
+					// copy into the array
+					reflect.Copy(v, reflect.ValueOf(bytes))
+				} else {
+					v.Set(reflect.ValueOf(bytes))
+				}
+				return nil
+			}
+		}
+		elem := planFor(typ.Elem())
+		sliceType := reflect.SliceOf(typ.Elem())
+		return func(p *Parcel, pr *preader, v reflect.Value) error {
+			s := reflect.Zero(sliceType)
+			for a := pr.array(); a.Next(); {
+				s = reflect.Append(s, reflect.Zero(typ.Elem()))
+				err := elem.decoder()(p, pr, s.Index(s.Len()-1))
+				if err != nil {
+					return err
+				}
 			}
 			if v.Kind() == reflect.Array {
 				// copy into the array
-				for i := 0; i < min(len(bytes), v.Len()); i++ {
-					v.Index(i).SetUint(uint64(bytes[i]))
-				}
+				reflect.Copy(v, s)
 			} else {
-				v.Set(reflect.ValueOf(bytes))
+				v.Set(s)
 			}
 			return nil
 		}
-		s := reflect.New(reflect.SliceOf(elemTyp)).Elem()
-		for a := r.Array(); a.Next(); {
-			v := reflect.New(elemTyp).Elem()
-			err := p.jsonLoadReader(pr, v)
-			if err != nil {
-				return err
+	}
+	return func(p *Parcel, pr *preader, v reflect.Value) error {
+		return nil
+	}
+}
+
+// loadInterface loads an interface field.  Assets are saved as their
+// path, pointers to structs are saved in place and are loaded into the
+// value the field already holds, since the file does not say which type
+// it was.
+func (p *Parcel) loadInterface(pr *preader, v reflect.Value) error {
+	a := pr.r.Any()
+	switch a.Kind {
+	case jreader.NullValue:
+		return pr.r.Error()
+	case jreader.StringValue:
+	case jreader.ObjectValue:
+		if v.IsNil() || !isInlineInterface(v.Elem()) {
+			// nothing to load into, skip it
+			for obj := a.Object; obj.Next(); {
 			}
-			s = reflect.Append(s, v)
+			return nil
 		}
-		if v.Kind() == reflect.Array {
-			// copy into the array
-			for i := 0; i < v.Len(); i++ {
-				v.Index(i).Set(s.Index(i))
+		return pr.replay(a, func(pr *preader) error {
+			return p.jsonLoadReader(pr, v.Elem().Elem())
+		})
+	default:
+		if err := pr.r.Error(); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: interface field %s holds a %s, not an asset path or an object", ErrInvalidType, v.Type(), a.Kind)
+	}
+	refPath := p.normPath(a.String)
+	end := p.trace(OpResolve, refPath, v.Type(), "")
+	loaded, err := p.loadAny(refPath)
+	end(err)
+	if err != nil {
+		return err
+	}
+	lv := reflect.ValueOf(loaded)
+	if !lv.Type().AssignableTo(v.Type()) {
+		return p.newError("load", refPath, lv.Type(), fmt.Errorf("%w: does not implement %s", ErrInvalidType, v.Type()))
+	}
+	v.Set(lv)
+	return nil
+}
+
+// headerType returns the Type header of an asset file, without reading
+// the rest of the file.
+func headerType(data []byte) (string, error) {
+	r := jreader.NewReader(data)
+	for obj := r.Object(); obj.Next(); {
+		if string(obj.Name()) == "Type" {
+			return r.String(), r.Error()
+		}
+	}
+	return "", r.Error()
+}
+
+// loadAny loads the asset at path as the type named in its header.
+func (p *Parcel) loadAny(path string) (any, error) {
+	if obj, ok := p.objectAt(path); ok {
+		return obj, nil
+	}
+	data, err := p.ReadAsset(path)
+	if err != nil {
+		return nil, err
+	}
+	name, err := headerType(data)
+	if err != nil {
+		return nil, &DecodeError{Path: path, Chain: slices.Clone(p.loadStack), Err: err}
+	}
+	typ, ok := p.typeByName(name)
+	if !ok {
+		return nil, p.newError("load", path, nil, fmt.Errorf("%w: %q", ErrUnknownType, name))
+	}
+	return p.Load(reflect.Zero(typ).Interface(), path)
+}
+
+// applyFieldRules sets the default of every field that was not seen
+// and reports required fields that were not seen.  Struct fields that
+// were not seen have the rules of their own fields applied.
+func (p *Parcel) applyFieldRules(plan *typePlan, v reflect.Value, seen []bool) error {
+	var errs []error
+	for i, field := range plan.fields {
+		if seen[i] {
+			continue
+		}
+		fv, err := v.FieldByIndexErr(field.index)
+		if err != nil {
+			continue // promoted through a nil embedded pointer
+		}
+		switch {
+		case field.required:
+			errs = append(errs, fmt.Errorf("field %s: %w", field.name, ErrRequired))
+		case field.hasDefault:
+			if err := p.setFromTag(fv, field.defaultValue); err != nil {
+				errs = append(errs, fmt.Errorf("field %s: default %q: %w", field.name, field.defaultValue, err))
+			}
+		case field.typ.Kind() == reflect.Struct && planFor(field.typ).hasRules:
+			inner := planFor(field.typ)
+			if err := p.applyFieldRules(inner, fv, make([]bool, len(inner.fields))); err != nil {
+				errs = append(errs, fmt.Errorf("field %s: %w", field.name, err))
 			}
-		} else {
-			v.Set(s)
 		}
+	}
+	return errors.Join(errs...)
+}
+
+// integers beyond this magnitude lose precision as a float64
+const maxExactInt = 1 << 53
+
+func writeInt(w *jwriter.Writer, n int64) {
+	if n > maxExactInt || n < -maxExactInt {
+		w.String(strconv.FormatInt(n, 10))
+		return
+	}
+	w.Raw(strconv.AppendInt(nil, n, 10))
+}
+
+func writeUint(w *jwriter.Writer, n uint64) {
+	if n > maxExactInt {
+		w.String(strconv.FormatUint(n, 10))
+		return
+	}
+	w.Raw(strconv.AppendUint(nil, n, 10))
+}
+
+func readInt(r *jreader.Reader) (int64, error) {
+	a := r.Any()
+	switch a.Kind {
+	case jreader.StringValue:
+		return strconv.ParseInt(a.String, 10, 64)
+	case jreader.NumberValue:
+		if a.Number != math.Trunc(a.Number) || a.Number < math.MinInt64 || a.Number >= math.MaxInt64 {
+			return 0, fmt.Errorf("%w: %v is not an int64", ErrInvalidType, a.Number)
+		}
+		return int64(a.Number), nil
+	}
+	if err := r.Error(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("%w: expected an integer, not %s", ErrInvalidType, a.Kind)
+}
 
+func readUint(r *jreader.Reader) (uint64, error) {
+	a := r.Any()
+	switch a.Kind {
+	case jreader.StringValue:
+		return strconv.ParseUint(a.String, 10, 64)
+	case jreader.NumberValue:
+		if a.Number != math.Trunc(a.Number) || a.Number < 0 || a.Number >= math.MaxUint64 {
+			return 0, fmt.Errorf("%w: %v is not a uint64", ErrInvalidType, a.Number)
+		}
+		return uint64(a.Number), nil
 	}
-	return nil
+	if err := r.Error(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("%w: expected an integer, not %s", ErrInvalidType, a.Kind)
 }
 
-func resolveKeyName(k reflect.Value) (string, error) {
+var (
+	textMarshaler   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// checkKeyType returns an error if map keys of typ cannot be saved and
+// loaded.  Pointer keys are saved as the path of the asset they point
+// to, whether that asset's type is registered is checked when saving.
+func checkKeyType(typ reflect.Type) error {
+	if typ.Kind() == reflect.String ||
+		(typ.Implements(textMarshaler) && reflect.PointerTo(typ).Implements(textUnmarshaler)) {
+		return nil
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Bool, reflect.Float32, reflect.Float64:
+		return nil
+	case reflect.Pointer:
+		if !isPointer(typ.Elem()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unsupported map key type %s", ErrInvalidType, typ)
+}
+
+func (p *Parcel) resolveKeyName(k reflect.Value) (string, error) {
 	if k.Kind() == reflect.String {
 		return k.String(), nil
 	}
@@ -277,34 +742,73 @@ func resolveKeyName(k reflect.Value) (string, error) {
 		return strconv.FormatInt(k.Int(), 10), nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 		return strconv.FormatUint(k.Uint(), 10), nil
+	case reflect.Bool:
+		return strconv.FormatBool(k.Bool()), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(k.Float(), 'g', -1, k.Type().Bits()), nil
+	case reflect.Pointer:
+		if _, known := p.objectNewFunc[k.Type()]; !known {
+			return "", p.newError("save", "", k.Type(), fmt.Errorf("%w: map keys must point to a registered type", ErrUnknownType))
+		}
+		if k.IsNil() {
+			return "", nil
+		}
+		path, ok := p.pathOf(k.Interface())
+		if !ok {
+			return "", p.newError("save", "", k.Type(), fmt.Errorf("%w: map keys must point to saved assets", ErrNoSavePath))
+		}
+		return path, nil
 	}
-	panic("unexpected map key type")
+	return "", checkKeyType(k.Type())
 }
 
-func makeKeyLoader(typ reflect.Type) func(key string) (reflect.Value, error) {
+func (p *Parcel) makeKeyLoader(typ reflect.Type) (func(key string) (reflect.Value, error), error) {
+	if err := checkKeyType(typ); err != nil {
+		return nil, err
+	}
 	if typ.Kind() == reflect.String {
 		return func(key string) (reflect.Value, error) {
 			return reflect.ValueOf(key).Convert(typ), nil
-		}
+		}, nil
 	}
-	if reflect.PointerTo(typ).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+	if reflect.PointerTo(typ).Implements(textUnmarshaler) {
 		return func(key string) (reflect.Value, error) {
 			v := reflect.New(typ)
 			err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key))
 			return v.Elem(), err
-		}
+		}, nil
 	}
 	switch typ.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return func(key string) (reflect.Value, error) {
 			n, err := strconv.ParseInt(key, 10, 64)
 			return reflect.ValueOf(n).Convert(typ), err
-		}
+		}, nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 		return func(key string) (reflect.Value, error) {
 			n, err := strconv.ParseUint(key, 10, 64)
 			return reflect.ValueOf(n).Convert(typ), err
-		}
+		}, nil
+	case reflect.Bool:
+		return func(key string) (reflect.Value, error) {
+			b, err := strconv.ParseBool(key)
+			return reflect.ValueOf(b).Convert(typ), err
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(key string) (reflect.Value, error) {
+			f, err := strconv.ParseFloat(key, typ.Bits())
+			return reflect.ValueOf(f).Convert(typ), err
+		}, nil
 	}
-	panic("unexpected map key type to load")
+	// pointers to assets, saved as their path
+	return func(key string) (reflect.Value, error) {
+		if key == "" {
+			return reflect.Zero(typ), nil
+		}
+		loaded, err := p.Load(reflect.Zero(typ).Interface(), key)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(loaded), nil
+	}, nil
 }
@@ -0,0 +1,72 @@
+package parcel
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type benchItem struct {
+	Name   string
+	Damage int
+	Weight float32
+	Tags   []string
+}
+
+type benchLevel struct {
+	Name    string
+	Items   []benchItem
+	Spawns  map[string]int
+	Seed    uint64
+	Enabled bool
+}
+
+func newBenchLevel() *benchLevel {
+	level := &benchLevel{Name: "level", Spawns: map[string]int{}, Seed: 42, Enabled: true}
+	for i := range 200 {
+		level.Items = append(level.Items, benchItem{
+			Name:   fmt.Sprint("item", i),
+			Damage: i,
+			Weight: float32(i) / 3,
+			Tags:   []string{"a", "b"},
+		})
+		level.Spawns[fmt.Sprint("spawn", i)] = i
+	}
+	return level
+}
+
+func BenchmarkJsonSave(b *testing.B) {
+	p := NewParcel()
+	level := newBenchLevel()
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := p.jsonSave(level); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJsonLoad(b *testing.B) {
+	p := NewParcel()
+	data, err := p.jsonSave(newBenchLevel())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for range b.N {
+		if err := p.jsonLoad(&benchLevel{}, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestJsonBenchRoundTrip(t *testing.T) {
+	p := NewParcel()
+	level := newBenchLevel()
+	data, err := p.jsonSave(level)
+	assert.NoError(t, err)
+	back := &benchLevel{}
+	assert.NoError(t, p.jsonLoad(back, data))
+	assert.Equal(t, level, back)
+}
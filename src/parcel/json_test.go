@@ -1,7 +1,6 @@
 package parcel
 
 import (
-	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -62,8 +61,6 @@ func TestJsonWriteBasic(t *testing.T) {
 	b, err := p.jsonSave(&basic)
 	assert.NoError(t, err)
 
-	os.WriteFile("./testdata/jsonwritebasic.json", b, 0666)
-
 	back := basicTypes{}
 	err = p.jsonLoad(&back, b)
 	assert.NoError(t, err)
@@ -94,11 +91,10 @@ func TestJsonReplacePointerWithString(t *testing.T) {
 	SetSavePath(linked, "linked")
 
 	p := GetDefault()
-	p.objectFromPath["linked.parcel"] = linked
+	p.register("linked.parcel", linked)
 
 	bmain, err := p.jsonSave(main)
 	assert.NoError(t, err)
-	os.WriteFile("./testdata/replacePointerWithStringMain.json", bmain, 0666)
 
 	mainBack := basicWithPointer{}
 	err = p.jsonLoad(&mainBack, bmain)
@@ -107,7 +103,6 @@ func TestJsonReplacePointerWithString(t *testing.T) {
 
 	blinked, err := p.jsonSave(linked)
 	assert.NoError(t, err)
-	os.WriteFile("./testdata/replacePointerWithStringLinked.json", blinked, 0666)
 
 	otherBack := basicWithPointer{}
 	err = p.jsonLoad(&otherBack, blinked)
@@ -151,11 +146,100 @@ func TestJsonMarshaller(t *testing.T) {
 	b, err := p.jsonSave(custom)
 	assert.NoError(t, err)
 
-	os.WriteFile("./testdata/jsonmarshaller.json", b, 0666)
-
 	back := &customSaveLoaderTest{}
 	err = p.jsonLoad(&back, b)
 	assert.NoError(t, err)
 
 	assert.Equal(t, custom, back)
 }
+
+type keyAsset struct {
+	Name string
+}
+
+type mapKeys struct {
+	Bools  map[bool]string
+	Floats map[float64]int
+	Assets map[*keyAsset]int
+}
+
+type badMapKey struct {
+	Keys map[[2]int]string
+}
+
+func TestJsonMapKeys(t *testing.T) {
+	p := NewParcel()
+	store := NewMemoryFS()
+	p.RegisterFS(store, 0)
+	p.RegisterWriteableFS(store)
+	assert.NoError(t, p.AddType(&keyAsset{}))
+	assert.NoError(t, p.AddType(&mapKeys{}))
+
+	a := &keyAsset{Name: "a"}
+	assert.NoError(t, p.SetSavePath(a, "a"))
+	keys := &mapKeys{
+		Bools:  map[bool]string{true: "yes", false: "no"},
+		Floats: map[float64]int{0.5: 1, -2: 2},
+		Assets: map[*keyAsset]int{a: 3},
+	}
+	b, err := p.jsonSave(keys)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"a.parcel":3`)
+
+	back := mapKeys{}
+	assert.NoError(t, p.jsonLoad(&back, b))
+	assert.Equal(t, keys, &back)
+
+	_, err = p.jsonSave(&mapKeys{Assets: map[*keyAsset]int{{Name: "unsaved"}: 1}})
+	assert.ErrorIs(t, err, ErrNoSavePath)
+}
+
+func TestJsonInvalidMapKeys(t *testing.T) {
+	p := NewParcel()
+	err := p.AddType(&badMapKey{})
+	assert.ErrorIs(t, err, ErrInvalidType)
+	assert.ErrorContains(t, err, "field Keys")
+
+	_, err = p.jsonSave(&badMapKey{Keys: map[[2]int]string{{1, 2}: "x"}})
+	assert.ErrorIs(t, err, ErrInvalidType)
+	err = p.jsonLoad(&badMapKey{}, []byte(`{"Keys":{"x":"y"}}`))
+	assert.ErrorIs(t, err, ErrInvalidType)
+}
+
+type nilPointers struct {
+	Items  []*unknownType
+	Nums   []*int
+	ByName map[string]*unknownType
+	Lists  []*[]int
+}
+
+func TestJsonNilPointersInContainers(t *testing.T) {
+	p := NewParcel()
+	one := 1
+	obj := nilPointers{
+		Items:  []*unknownType{{Unknown: "a"}, nil},
+		Nums:   []*int{nil, &one},
+		ByName: map[string]*unknownType{"none": nil, "some": {Unknown: "b"}},
+		Lists:  []*[]int{nil, {1, 2}},
+	}
+	b, err := p.jsonSave(&obj)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"Items":[{"Unknown":"a"},null]`)
+
+	back := nilPointers{}
+	err = p.jsonLoad(&back, b)
+	assert.NoError(t, err)
+	assert.Equal(t, obj, back)
+}
+
+func TestJsonLoadIntoExistingMap(t *testing.T) {
+	p := GetDefault()
+	b, err := p.jsonSave(&basic)
+	assert.NoError(t, err)
+
+	back := basicTypes{Map: map[string]int{"a": 5, "z": 9}}
+	err = p.jsonLoad(&back, b)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 0, "b": 1, "z": 9}, back.Map)
+	assert.Equal(t, basic.MapInt, back.MapInt, "later fields still load")
+}
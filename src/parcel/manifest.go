@@ -0,0 +1,129 @@
+package parcel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ManifestFileName is the conventional name of a project manifest.
+const ManifestFileName = "parcel.json"
+
+// Manifest describes how a project's Parcel is set up, so that every
+// app and tool builds the same one.  A manifest looks like
+//
+//	{
+//	  "fs": [{"dir": "mods", "priority": 0}, {"dir": "base", "priority": 10}],
+//	  "writable": "base",
+//	  "extension": ".parcel",
+//	  "codecs": {".yaml": "yaml"},
+//	  "typeAliases": {"*game.OldWeapon": "*game.Weapon"},
+//	  "format": {"indent": "  "}
+//	}
+//
+// Directories are relative to the manifest file.  The writable root is
+// only written to, list it in fs as well to load what is saved there.
+// An empty extension turns extensions off, see SetExtension.  Codecs
+// map extensions to the built in codecs, "json" or "yaml".
+type Manifest struct {
+	FS          []ManifestFS      `json:"fs"`
+	Writable    string            `json:"writable,omitempty"`
+	Extension   *string           `json:"extension,omitempty"`
+	Codecs      map[string]string `json:"codecs,omitempty"`
+	TypeAliases map[string]string `json:"typeAliases,omitempty"`
+	Format      FormatOptions     `json:"format"`
+
+	// directory the manifest was read from
+	dir string
+}
+
+type ManifestFS struct {
+	Dir      string `json:"dir"`
+	Priority int    `json:"priority"`
+}
+
+// LoadManifest reads the manifest at path.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseManifest(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// ParseManifest parses a manifest, dir is used to resolve relative
+// directories.
+func ParseManifest(data []byte, dir string) (*Manifest, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	m := &Manifest{dir: dir}
+	if err := dec.Decode(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewParcel returns a Parcel configured by the manifest.  Types still
+// need to be added with AddType.
+func (m *Manifest) NewParcel() (*Parcel, error) {
+	p := NewParcel()
+	for _, f := range m.FS {
+		dir := m.path(f.Dir)
+		if info, err := os.Stat(dir); err != nil {
+			return nil, err
+		} else if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", dir)
+		}
+		p.RegisterFS(os.DirFS(dir), f.Priority)
+	}
+	if m.Writable != "" {
+		p.RegisterWriteableFS(SimpleWritableFS(m.path(m.Writable)))
+	}
+	for ext, name := range m.Codecs {
+		var c Codec
+		switch name {
+		case "json":
+			c = jsonCodec{p}
+		case "yaml":
+			c = &YAMLCodec{}
+		default:
+			return nil, fmt.Errorf("unknown codec %q for %s", name, ext)
+		}
+		if err := p.RegisterCodec(ext, c); err != nil {
+			return nil, err
+		}
+	}
+	if m.Extension != nil {
+		if err := p.SetExtension(*m.Extension); err != nil {
+			return nil, err
+		}
+	}
+	for alias, name := range m.TypeAliases {
+		p.AddTypeAlias(alias, name)
+	}
+	p.SetFormatOptions(m.Format)
+	return p, nil
+}
+
+func (m *Manifest) path(dir string) string {
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(m.dir, dir)
+}
+
+// NewParcelFromManifest loads the manifest at path and returns the
+// Parcel it describes.
+func NewParcelFromManifest(path string) (*Parcel, error) {
+	m, err := LoadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	return m.NewParcel()
+}
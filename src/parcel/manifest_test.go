@@ -0,0 +1,69 @@
+package parcel_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"base", "mods"} {
+		assert.NoError(t, os.Mkdir(filepath.Join(root, dir), 0o755))
+	}
+	os.WriteFile(filepath.Join(root, "base", "a.parcel"),
+		[]byte(`{"Type":"*parcel_test.testType","Obj":{"String":"base"}}`), 0o644)
+	os.WriteFile(filepath.Join(root, "mods", "a.parcel"),
+		[]byte(`{"Type":"*parcel_test.testType","Obj":{"String":"mod"}}`), 0o644)
+	manifest := filepath.Join(root, parcel.ManifestFileName)
+	os.WriteFile(manifest, []byte(`{
+		"fs": [{"dir": "base", "priority": 10}, {"dir": "mods", "priority": 0}],
+		"writable": "base",
+		"extension": ".parcel",
+		"codecs": {".yaml": "yaml"},
+		"typeAliases": {"*game.OldType": "*parcel_test.testType"},
+		"format": {"indent": "  "}
+	}`), 0o644)
+
+	p, err := parcel.NewParcelFromManifest(manifest)
+	assert.NoError(t, err)
+	p.AddType(&testType{})
+	assert.Equal(t, parcel.FormatOptions{Indent: "  "}, p.GetFormatOptions())
+	assert.True(t, p.IsKnownType("*game.OldType"))
+	assert.False(t, p.IsKnownType("*game.Other"))
+
+	a, err := p.Load(&testType{}, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "mod", a.(*testType).String, "lower priority numbers win")
+
+	assert.NoError(t, p.SetSavePath(&testType{String: "new"}, "b"))
+	data, err := os.ReadFile(filepath.Join(root, "base", "b.parcel"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "\n  \"Type\"")
+
+	assert.NoError(t, p.SetSavePath(&testType{String: "yaml"}, "c.yaml"))
+	data, err = os.ReadFile(filepath.Join(root, "base", "c.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "String: yaml\n")
+}
+
+func TestManifestErrors(t *testing.T) {
+	_, err := parcel.ParseManifest([]byte(`{"fs": [], "bogus": 1}`), ".")
+	assert.ErrorContains(t, err, "bogus")
+
+	m, err := parcel.ParseManifest([]byte(`{"fs": [{"dir": "missing"}]}`), t.TempDir())
+	assert.NoError(t, err)
+	_, err = m.NewParcel()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	m, err = parcel.ParseManifest([]byte(`{"codecs": {".bin": "cbor"}}`), ".")
+	assert.NoError(t, err)
+	_, err = m.NewParcel()
+	assert.ErrorContains(t, err, `unknown codec "cbor"`)
+
+	_, err = parcel.LoadManifest(filepath.Join(t.TempDir(), "nope.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
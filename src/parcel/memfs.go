@@ -0,0 +1,206 @@
+package parcel
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryFS is an in-memory filesystem that implements both WritableFS
+// and fs.FS, so the same instance can be passed to RegisterWriteableFS
+// and RegisterFS.  It is useful for tests, scratch assets and sandboxed
+// previews where nothing should touch the disk.
+// A MemoryFS is safe for concurrent use.
+type MemoryFS struct {
+	mu    sync.RWMutex
+	files map[string]*memFileData
+}
+
+type memFileData struct {
+	data    []byte
+	modTime time.Time
+}
+
+func NewMemoryFS() *MemoryFS {
+	return &MemoryFS{files: make(map[string]*memFileData)}
+}
+
+// WriteFile stores a copy of data at name, replacing any existing file.
+func (m *MemoryFS) WriteFile(name string, data []byte) error {
+	name = path.Clean(name)
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.isDirLocked(name) {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrExist}
+	}
+	m.files[name] = &memFileData{data: slices.Clone(data), modTime: time.Now()}
+	return nil
+}
+
+// DeleteFile removes the file at name.
+func (m *MemoryFS) DeleteFile(name string) error {
+	name = path.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "delete", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+// Open implements fs.FS.  Directories are implied by the files they contain.
+func (m *MemoryFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if f, ok := m.files[name]; ok {
+		return &memFile{
+			name:   path.Base(name),
+			data:   f,
+			reader: strings.NewReader(string(f.data)),
+		}, nil
+	}
+	if m.isDirLocked(name) {
+		return &memDir{name: path.Base(name), entries: m.readDirLocked(name)}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (m *MemoryFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if f, ok := m.files[name]; ok {
+		return slices.Clone(f.data), nil
+	}
+	return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *MemoryFS) isDirLocked(name string) bool {
+	if name == "." {
+		return true
+	}
+	prefix := name + "/"
+	for p := range m.files {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryFS) readDirLocked(name string) []fs.DirEntry {
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	seen := map[string]fs.DirEntry{}
+	for p, f := range m.files {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		rest := p[len(prefix):]
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			dir := rest[:i]
+			seen[dir] = fs.FileInfoToDirEntry(memFileInfo{name: dir, dir: true})
+		} else {
+			seen[rest] = fs.FileInfoToDirEntry(memFileInfo{name: rest, data: f})
+		}
+	}
+	entries := make([]fs.DirEntry, 0, len(seen))
+	for _, e := range seen {
+		entries = append(entries, e)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries
+}
+
+type memFileInfo struct {
+	name string
+	dir  bool
+	data *memFileData
+}
+
+func (i memFileInfo) Name() string { return i.name }
+func (i memFileInfo) Size() int64 {
+	if i.data == nil {
+		return 0
+	}
+	return int64(len(i.data.data))
+}
+func (i memFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+func (i memFileInfo) ModTime() time.Time {
+	if i.data == nil {
+		return time.Time{}
+	}
+	return i.data.modTime
+}
+func (i memFileInfo) IsDir() bool { return i.dir }
+func (i memFileInfo) Sys() any    { return nil }
+
+type memFile struct {
+	name   string
+	data   *memFileData
+	reader *strings.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	return memFileInfo{name: f.name, data: f.data}, nil
+}
+func (f *memFile) Read(b []byte) (int, error) { return f.reader.Read(b) }
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	return f.reader.Seek(offset, whence)
+}
+func (f *memFile) ReadAt(b []byte, offset int64) (int, error) {
+	return f.reader.ReadAt(b, offset)
+}
+func (f *memFile) Close() error { return nil }
+
+type memDir struct {
+	name    string
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) {
+	return memFileInfo{name: d.name, dir: true}, nil
+}
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+func (d *memDir) Close() error { return nil }
+
+// ReadDir implements fs.ReadDirFile.
+func (d *memDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if count <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	count = min(count, len(remaining))
+	d.offset += count
+	return remaining[:count], nil
+}
@@ -0,0 +1,43 @@
+package parcel_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryFS(t *testing.T) {
+	m := parcel.NewMemoryFS()
+	assert.NoError(t, m.WriteFile("a.parcel", []byte("a")))
+	assert.NoError(t, m.WriteFile("dir/b.parcel", []byte("b")))
+	assert.NoError(t, m.WriteFile("dir/sub/c.parcel", []byte("c")))
+
+	assert.NoError(t, fstest.TestFS(m, "a.parcel", "dir/b.parcel", "dir/sub/c.parcel"))
+
+	data, err := fs.ReadFile(m, "dir/b.parcel")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+
+	_, err = m.Open("missing.parcel")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	assert.Error(t, m.WriteFile("dir", []byte("dir")), "cannot overwrite a directory")
+	assert.Error(t, m.WriteFile("../escape", []byte("x")))
+}
+
+func TestMemoryFSCopiesData(t *testing.T) {
+	m := parcel.NewMemoryFS()
+	buf := []byte("original")
+	m.WriteFile("a", buf)
+	buf[0] = 'X'
+
+	data, _ := m.ReadFile("a")
+	assert.Equal(t, "original", string(data))
+	data[0] = 'Y'
+
+	data, _ = m.ReadFile("a")
+	assert.Equal(t, "original", string(data))
+}
@@ -73,6 +73,79 @@ func Save(T any) error {
 	return d.Save(T)
 }
 
+// SaveChanged saves T, skipping the write if nothing has changed.
+func SaveChanged(T any) (bool, error) {
+	return d.SaveChanged(T)
+}
+
+// IsDirty reports whether T has changes that have not been saved.
+func IsDirty(T any) bool {
+	return d.IsDirty(T)
+}
+
+// DirtyAssets returns the sorted paths of loaded assets with unsaved
+// changes.
+func DirtyAssets() []string {
+	return d.DirtyAssets()
+}
+
+// SaveAllDirty saves every loaded asset with unsaved changes.
+func SaveAllDirty() ([]string, error) {
+	return d.SaveAllDirty()
+}
+
+// Hash returns a stable digest of the saved form of T.
+func Hash(T any) (string, error) {
+	return d.Hash(T)
+}
+
+// Validate checks all registered types, including interface fields.
+func Validate() error {
+	return d.Validate()
+}
+
+// Assets lists every asset in the registered filesystems.
+func Assets() ([]string, error) {
+	return d.Assets()
+}
+
+// JSONSchema returns a JSON Schema for asset files holding a T.
+func JSONSchema[T any]() ([]byte, error) {
+	var t *T
+	return d.JSONSchema(t)
+}
+
+// Describe returns the properties of obj for building editors.
+func Describe(obj any) (*Property, error) {
+	return d.Describe(obj)
+}
+
+// SetProperty sets the value at a field path in obj.
+func SetProperty(obj any, path string, value any) error {
+	return d.SetProperty(obj, path, value)
+}
+
+// GetField returns the value at a field path in obj, such as
+// "Stats.Weapons[2].Damage".
+func GetField(obj any, path string) (any, error) {
+	return d.GetField(obj, path)
+}
+
+// SetField sets the value at a field path in obj, see Parcel.SetProperty
+// for how values are converted.
+func SetField(obj any, path string, value any) error {
+	return d.SetField(obj, path, value)
+}
+
+// ForEach calls fn with every asset of type T and saves the ones it
+// modifies, see Parcel.ForEach.
+func ForEach[T any](fn func(path string, obj *T) (modified bool, err error), opts ForEachOptions) (*ForEachReport, error) {
+	var t *T
+	return d.ForEach(t, func(path string, obj any) (bool, error) {
+		return fn(path, obj.(*T))
+	}, opts)
+}
+
 func SetParent[T any](child *T, parent *T) error {
 	return d.SetParent(child, parent)
 }
@@ -81,6 +154,34 @@ func Delete(path string) error {
 	return d.Delete(path)
 }
 
+func Move(from string, to string) error {
+	return d.Move(from, to)
+}
+
+// Unload forgets the asset at path, the next Load reads it from disk.
+func Unload(path string) {
+	d.Unload(path)
+}
+
+// Begin starts a transaction on the default Parcel.
+func Begin() *Txn {
+	return d.Begin()
+}
+
+// Diff returns the field level changes needed to turn a into b.
+func Diff(a any, b any) ([]Change, error) {
+	return d.Diff(a, b)
+}
+
+// Merge performs a three way merge of ours and theirs against base.
+func Merge[T any](base *T, ours *T, theirs *T) (*T, []Conflict, error) {
+	merged, conflicts, err := d.Merge(base, ours, theirs)
+	if err == nil && merged != nil {
+		return merged.(*T), conflicts, nil
+	}
+	return nil, nil, err
+}
+
 var d *Parcel = NewParcel()
 
 func GetDefault() *Parcel {
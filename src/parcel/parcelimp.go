@@ -1,14 +1,16 @@
 package parcel
 
 import (
+	"bytes"
 	"cmp"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
-	"path/filepath"
+	"log/slog"
 	"reflect"
 	"slices"
+	"time"
 )
 
 type Parcel struct {
@@ -18,19 +20,42 @@ type Parcel struct {
 	objectFromPath map[string]any
 	pathFromObject map[any]string
 	loadableTypes  map[reflect.Type]reflect.Type
+	typeAliases    map[string]string
+	ext            string
+	codecs         map[string]Codec
+	weakCache      bool
+	cleanHash      map[string]string
+	extras         map[string]extraFields
+	history        *history
+	listeners      []ChangeListener
+	format         FormatOptions
+	stats          statsCounters
+	logger         *slog.Logger
+	tracer         Tracer
+	// paths of the assets currently being loaded, outermost first
+	loadStack []string
 }
 
 func NewParcel() *Parcel {
-	return &Parcel{
+	p := &Parcel{
 		objectNewFunc:  make(map[reflect.Type]func() (any, error)),
 		objectFromPath: make(map[string]any),
 		pathFromObject: make(map[any]string),
 		loadableTypes:  make(map[reflect.Type]reflect.Type),
+		typeAliases:    make(map[string]string),
+		cleanHash:      make(map[string]string),
+		extras:         make(map[string]extraFields),
+		stats:          newStatsCounters(),
+		ext:            defaultExt,
+		codecs:         make(map[string]Codec),
 	}
+	p.codecs[defaultExt] = jsonCodec{p}
+	return p
 }
 
 func (p *Parcel) RegisterFS(fsys fs.FS, priority int) {
-	p.fsys = append(p.fsys, fsPriority{fsys, priority})
+	name := fmt.Sprintf("#%d %T (priority %d)", len(p.fsys), fsys, priority)
+	p.fsys = append(p.fsys, fsPriority{fsys, priority, name})
 	slices.SortStableFunc(p.fsys, func(a fsPriority, b fsPriority) int {
 		return cmp.Compare(a.priority, b.priority)
 	})
@@ -48,13 +73,20 @@ func (p *Parcel) AddType(T any) error {
 	})
 }
 
+// AddFactoryForType registers T with a function that creates new objects
+// of it, for New and for Load to decode into.  Fields with a default tag
+// that are missing from a loaded file get the default, not the value the
+// factory set.
 func (p *Parcel) AddFactoryForType(T any, create func() (any, error)) error {
 	typ := reflect.TypeOf(T)
 	if !isPointer(typ) {
-		return fmt.Errorf("Type being registered must be a pointer")
+		return p.newError("register", "", typ, fmt.Errorf("%w: type being registered must be a pointer", ErrInvalidType))
 	}
 	if isPointer(typ.Elem()) {
-		return fmt.Errorf("Type being registered must be a pointer that dereferences to a concrete type")
+		return p.newError("register", "", typ, fmt.Errorf("%w: type being registered must be a pointer that dereferences to a concrete type", ErrInvalidType))
+	}
+	if err := p.checkType(typ, false); err != nil {
+		return p.newError("register", "", typ, err)
 	}
 	p.objectNewFunc[typ] = create
 	return nil
@@ -67,7 +99,9 @@ func (p *Parcel) New(T any) (any, error) {
 
 func (p *Parcel) newFromType(typ reflect.Type) (any, error) {
 	if fn, ok := p.objectNewFunc[typ]; ok {
+		end := p.trace(OpCreate, "", typ, "")
 		newObj, err := fn()
+		end(err)
 		if err == nil {
 			if postcreator, ok := newObj.(PostCreator); ok {
 				postcreator.PostCreate()
@@ -75,15 +109,15 @@ func (p *Parcel) newFromType(typ reflect.Type) (any, error) {
 		}
 		return newObj, err
 	}
-	return nil, fmt.Errorf("unknown asset type %s, make sure this type is added", typ.String())
+	return nil, p.newError("new", "", typ, ErrUnknownType)
 }
 
 func (p *Parcel) SetSavePath(T any, path string) error {
-	if _, exists := p.objectFromPath[path]; exists {
-		return fmt.Errorf("cannot SetSavePath at path '%s' because it already exists", path)
+	path = p.normPath(path)
+	if _, exists := p.objectAt(path); exists {
+		return p.newError("setsavepath", path, reflect.TypeOf(T), ErrPathExists)
 	}
-	path = normPath(path)
-	p.pathFromObject[T] = path
+	p.register(path, T)
 	return p.Save(T)
 }
 
@@ -96,67 +130,297 @@ type diskSaveFormat struct {
 // Load takes a pointer to a type and a path.  A new object of type will be created,
 // the on-disk meta format (a variation on diskSaveFormat) for T will be loaded and
 // finally the newly created T will be returned.
+// The object is registered at path before it is decoded, so assets that
+// refer back to it, directly or through a cycle, get the same object.
 func (p *Parcel) Load(T any, path string) (any, error) {
-	path = normPath(path)
-	if obj, exists := p.objectFromPath[path]; exists {
+	path = p.normPath(path)
+	if obj, exists := p.objectAt(path); exists {
+		p.stats.hits++
 		return obj, nil
 	}
-	data, e1 := p.ReadFile(path)
-	loadableType, e2 := p.getLoadableSaveFormatType(reflect.TypeOf(T))
+	p.stats.misses++
+	end := p.trace(OpLoad, path, reflect.TypeOf(T), "")
+	obj, err := p.load(T, path)
+	end(err)
+	return obj, err
+}
 
+func (p *Parcel) load(T any, path string) (any, error) {
+	start := time.Now()
+	data, e1 := p.ReadAsset(path)
+	_, e2 := p.getLoadableSaveFormatType(reflect.TypeOf(T))
 	if err := errors.Join(e1, e2); err != nil {
 		return nil, err
 	}
+	return p.loadData(T, path, data, start)
+}
 
+// loadRead is Load for an asset that is not loaded yet and whose data
+// has already been read.
+func (p *Parcel) loadRead(T any, path string, data []byte) (any, error) {
+	p.stats.misses++
+	end := p.trace(OpLoad, path, reflect.TypeOf(T), "")
+	obj, err := p.loadData(T, path, data, time.Now())
+	end(err)
+	return obj, err
+}
+
+// loadData is load for an asset whose data has already been read.
+func (p *Parcel) loadData(T any, path string, data []byte, start time.Time) (any, error) {
+	loadableType, err := p.getLoadableSaveFormatType(reflect.TypeOf(T))
+	if err != nil {
+		return nil, err
+	}
+
+	newObj, err := p.newFromType(reflect.TypeOf(T))
+	if err != nil {
+		newObj = reflect.New(reflect.TypeOf(T).Elem()).Interface()
+	}
 	loadableV := reflect.New(loadableType)
-	err := p.jsonLoad(loadableV.Interface(), data)
+	loadableV.Elem().FieldByName("Obj").Set(reflect.ValueOf(newObj))
+
+	// register before decoding so that references back to this
+	// path resolve to the object being loaded
+	p.register(path, newObj)
+	p.loadStack = append(p.loadStack, path)
+	unknown, err := p.jsonLoadTracked(loadableV.Interface(), data)
+	if err != nil && !isAssetError(err) {
+		err = &DecodeError{Path: path, Type: typeStr(reflect.TypeOf(T)), Chain: slices.Clone(p.loadStack[:len(p.loadStack)-1]), Err: err}
+	}
+	p.loadStack = p.loadStack[:len(p.loadStack)-1]
 	if err != nil {
+		p.unregister(path)
 		return nil, err
 	}
 
-	newObj := loadableV.Elem().FieldByName("Obj").Interface()
-	if postloader, ok := newObj.(PostLoader); ok {
-		postloader.PostLoad()
+	delete(p.extras, path)
+	if unknown {
+		// data has already been decoded, so it parses
+		if extras, err := captureExtras(loadableType, data); err == nil && extras != nil {
+			p.extras[path] = extras
+		}
+	}
+
+	if planFor(reflect.TypeOf(newObj)).postLoad {
+		newObj.(PostLoader).PostLoad()
 	}
+	if canonical, err := p.canonicalData(newObj); err == nil {
+		p.markClean(path, canonical)
+	}
+	if err := p.historyLoaded(newObj); err != nil {
+		return nil, err
+	}
+	p.stats.loadTime[path] = time.Since(start)
 	return newObj, nil
 }
 
 func (p *Parcel) Save(T any) error {
+	_, err := p.SaveChanged(T)
+	return err
+}
+
+// SaveChanged saves T like Save, but skips the write if the bytes in
+// the WritableFS already match.  It reports whether anything was written.
+func (p *Parcel) SaveChanged(T any) (changed bool, err error) {
 	if p.writefs == nil {
-		return fmt.Errorf("No WritableFS has been registered yet")
+		return false, p.newError("save", "", reflect.TypeOf(T), ErrNoWritableFS)
 	}
-	path, exists := p.pathFromObject[T]
+	path, exists := p.pathOf(T)
 	if !exists {
-		return fmt.Errorf("object has no save path.  Call SetSavePath first")
+		return false, p.newError("save", "", reflect.TypeOf(T), ErrNoSavePath)
 	}
-	p.objectFromPath[path] = T
-	toSave := diskSaveFormat{
-		Type: typeStr(reflect.TypeOf(T)),
-		Obj:  T,
+	end := p.trace(OpSave, path, reflect.TypeOf(T), "")
+	defer func() { end(err) }()
+	p.register(path, T)
+	canonical, err := p.canonicalData(T)
+	if err != nil {
+		return false, err
+	}
+	stored := canonical
+	if extras, ok := p.extras[path]; ok {
+		loadableType, err := p.getLoadableSaveFormatType(reflect.TypeOf(T))
+		if err != nil {
+			return false, err
+		}
+		if stored, err = mergeExtras(loadableType, canonical, extras); err != nil {
+			return false, err
+		}
 	}
-	data, err := p.jsonSave(toSave)
+	data, err := p.CodecFor(path).Encode(path, stored)
 	if err != nil {
-		return err
+		return false, err
+	}
+	changed = true
+	if current, ok := p.readWritable(path); ok && bytes.Equal(current, data) {
+		changed = false
+	} else if err := p.writefs.WriteFile(path, data); err != nil {
+		return false, err
+	}
+	p.markClean(path, canonical)
+	return changed, p.historySaved(T)
+}
+
+// canonicalData returns the compact on-disk form of T.
+func (p *Parcel) canonicalData(T any) ([]byte, error) {
+	return p.jsonSaveAsset(diskSaveFormat{
+		Type: typeStr(reflect.TypeOf(T)),
+		Obj:  T,
+	})
+}
+
+// readWritable returns the current contents of path in the WritableFS,
+// if the WritableFS can be read from.
+func (p *Parcel) readWritable(path string) ([]byte, bool) {
+	readable, ok := p.writefs.(fs.FS)
+	if !ok {
+		return nil, false
 	}
-	return p.writefs.WriteFile(path, data)
+	data, err := fs.ReadFile(readable, path)
+	return data, err == nil
 }
 
 func (p *Parcel) SetParent(child any, parent any) error {
 	return nil
 }
 
+// Delete removes the asset at path from the WritableFS.  If the asset
+// is loaded it is forgotten, but any objects that still reference it
+// are left untouched.
 func (p *Parcel) Delete(path string) error {
+	if p.writefs == nil {
+		return p.newError("delete", path, nil, ErrNoWritableFS)
+	}
+	path = p.normPath(path)
+	if err := p.writefs.DeleteFile(path); err != nil {
+		return err
+	}
+	p.unregister(path)
+	delete(p.cleanHash, path)
+	delete(p.extras, path)
+	p.forgetCodecState(path)
+	return nil
+}
+
+// Move renames the asset at from to to.  If the asset is loaded, the
+// object keeps its identity and is saved at the new path, otherwise
+// the file is copied from whichever filesystem it is found in.
+// The file at from is then deleted from the WritableFS.
+func (p *Parcel) Move(from string, to string) error {
+	if p.writefs == nil {
+		return p.newError("move", from, nil, ErrNoWritableFS)
+	}
+	from, to = p.normPath(from), p.normPath(to)
+	if p.exists(to) {
+		return p.newError("move", to, nil, ErrPathExists)
+	}
+	if obj, loaded := p.objectAt(from); loaded {
+		hash, hashed := p.cleanHash[from]
+		extras, hasExtras := p.extras[from]
+		p.unregister(from)
+		delete(p.cleanHash, from)
+		delete(p.extras, from)
+		if hasExtras {
+			p.extras[to] = extras
+		}
+		p.register(to, obj)
+		p.renameCodecState(from, to)
+		if err := p.Save(obj); err != nil {
+			// nothing was written, leave the object where it was
+			p.renameCodecState(to, from)
+			p.unregister(to)
+			delete(p.extras, to)
+			p.register(from, obj)
+			if hashed {
+				p.cleanHash[from] = hash
+			}
+			if hasExtras {
+				p.extras[from] = extras
+			}
+			return err
+		}
+	} else {
+		data, err := p.ReadFile(from)
+		if err != nil {
+			return err
+		}
+		if data, err = p.convert(from, to, data); err != nil {
+			return err
+		}
+		if err := p.writefs.WriteFile(to, data); err != nil {
+			return err
+		}
+		p.renameCodecState(from, to)
+	}
+	p.forgetCodecState(from)
+	// the source may only exist in a read only filesystem
+	if err := p.writefs.DeleteFile(from); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
 	return nil
 }
 
 func (p *Parcel) ReadFile(path string) ([]byte, error) {
 	for _, f := range p.fsys {
+		end := p.trace(OpReadFile, path, nil, f.name)
 		s, err := f.fsys.Open(path)
+		end(err)
 		if err == nil && s != nil {
-			return io.ReadAll(s)
+			data, err := io.ReadAll(s)
+			s.Close()
+			p.stats.bytesRead[f.name] += int64(len(data))
+			return data, err
+		}
+	}
+	return nil, p.newError("read", path, nil, ErrNotFound)
+}
+
+// exists reports whether path is a loaded asset or can be found in
+// any registered filesystem.
+func (p *Parcel) exists(path string) bool {
+	if _, loaded := p.objectAt(path); loaded {
+		return true
+	}
+	for _, f := range p.fsys {
+		if _, err := fs.Stat(f.fsys, path); err == nil {
+			return true
+		}
+	}
+	if readable, ok := p.writefs.(fs.FS); ok {
+		if _, err := fs.Stat(readable, path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTypeAlias makes files whose Type header is alias load as the
+// registered type written as name, such as "*game.Weapon".  Use it when
+// a type is renamed or moved to another package, so that existing files
+// still load.  Saving always writes the current name, and aliases are
+// not followed more than one step.
+func (p *Parcel) AddTypeAlias(alias string, name string) {
+	p.typeAliases[alias] = name
+}
+
+// IsKnownType reports whether name, as written in a Type header, is a
+// registered type or an alias of one.
+func (p *Parcel) IsKnownType(name string) bool {
+	_, ok := p.typeByName(name)
+	return ok
+}
+
+// typeByName returns the registered pointer type that is written as
+// name in the Type header of saved files.
+func (p *Parcel) typeByName(name string) (reflect.Type, bool) {
+	if target, ok := p.typeAliases[name]; ok {
+		name = target
+	}
+	for typ := range p.objectNewFunc {
+		if typeStr(typ) == name {
+			return typ, true
 		}
 	}
-	return nil, fmt.Errorf("unable to find filepath '%s' in any registered filesystem", path)
+	return nil, false
 }
 
 func (p *Parcel) getLoadableSaveFormatType(ptyp reflect.Type) (reflect.Type, error) {
@@ -175,6 +439,7 @@ func (p *Parcel) getLoadableSaveFormatType(ptyp reflect.Type) (reflect.Type, err
 type fsPriority struct {
 	fsys     fs.FS
 	priority int
+	name     string
 }
 
 func isPointer(t reflect.Type) bool {
@@ -188,12 +453,3 @@ func isStruct(t reflect.Type) bool {
 func typeStr(t reflect.Type) string {
 	return t.String()
 }
-
-const fileExt = ".parcel"
-
-func normPath(path string) string {
-	if filepath.Ext(path) != fileExt {
-		return path + fileExt
-	}
-	return path
-}
@@ -1,7 +1,6 @@
 package parcel_test
 
 import (
-	"os"
 	"testing"
 
 	"github.com/Bradbev/parcel/src/parcel"
@@ -26,7 +25,9 @@ func (t *testType) PostLoad() {
 }
 
 type setupOpts struct {
-	NoEraseStore bool
+	// Store is shared between parcels when set, otherwise
+	// a fresh empty store is created.
+	Store *parcel.MemoryFS
 }
 
 func newDefault() *parcel.Parcel {
@@ -35,14 +36,15 @@ func newDefault() *parcel.Parcel {
 	return p
 }
 
-func setupBasic(p *parcel.Parcel, opts setupOpts) {
-	if opts.NoEraseStore == false {
-		os.RemoveAll("./testdata")
-		os.Mkdir("./testdata", 0750)
+func setupBasic(p *parcel.Parcel, opts setupOpts) *parcel.MemoryFS {
+	store := opts.Store
+	if store == nil {
+		store = parcel.NewMemoryFS()
 	}
-	p.RegisterFS(os.DirFS("./testdata"), 0)
-	p.RegisterWriteableFS(parcel.SimpleWritableFS("./testdata"))
+	p.RegisterFS(store, 0)
+	p.RegisterWriteableFS(store)
 	p.AddType(&testType{})
+	return store
 }
 
 func TestNew(t *testing.T) {
@@ -83,7 +85,7 @@ func TestSaveLoad(t *testing.T) {
 }
 
 func TestSaveLoadPersist(t *testing.T) {
-	setupBasic(newDefault(), setupOpts{})
+	store := setupBasic(newDefault(), setupOpts{})
 
 	path := "testsaveload"
 	obj, _ := parcel.New[testType]()
@@ -96,7 +98,7 @@ func TestSaveLoadPersist(t *testing.T) {
 	assert.NoError(t, err)
 
 	p := parcel.NewParcel()
-	setupBasic(p, setupOpts{NoEraseStore: true})
+	setupBasic(p, setupOpts{Store: store})
 
 	obj2, err := p.Load(&testType{}, path)
 	assert.NoError(t, err)
@@ -115,7 +117,7 @@ func TestLoadError(t *testing.T) {
 }
 
 func TestSaveLoadWithIndirectObject(t *testing.T) {
-	setupBasic(newDefault(), setupOpts{})
+	store := setupBasic(newDefault(), setupOpts{})
 	mkobj := func(path string) (*testType, string) {
 		obj, _ := parcel.New[testType]()
 		obj.String = path
@@ -141,7 +143,7 @@ func TestSaveLoadWithIndirectObject(t *testing.T) {
 	/////// Load the objects
 
 	p := parcel.NewParcel()
-	setupBasic(p, setupOpts{NoEraseStore: true})
+	setupBasic(p, setupOpts{Store: store})
 
 	anyObj2, err := p.Load(&testType{}, path)
 	assert.NoError(t, err)
@@ -159,6 +161,29 @@ func TestSaveLoadWithIndirectObject(t *testing.T) {
 	assert.Equal(t, linkedL.OtherObj.OtherObj, linked2L)
 }
 
+func TestSaveLoadCyclicReferences(t *testing.T) {
+	store := setupBasic(newDefault(), setupOpts{})
+	a, _ := parcel.New[testType]()
+	b, _ := parcel.New[testType]()
+	a.String, b.String = "a", "b"
+	a.OtherObj, b.OtherObj = b, a
+	parcel.SetSavePath(a, "a")
+	parcel.SetSavePath(b, "b")
+	assert.NoError(t, parcel.Save(a))
+
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{Store: store})
+	loaded, err := p.Load(&testType{}, "a")
+	assert.NoError(t, err)
+	aL := loaded.(*testType)
+	assert.Equal(t, "b", aL.OtherObj.String)
+	assert.Same(t, aL, aL.OtherObj.OtherObj, "the cycle is closed with the same object")
+
+	loaded, err = p.Load(&testType{}, "b")
+	assert.NoError(t, err)
+	assert.Same(t, aL.OtherObj, loaded)
+}
+
 type basicTypes struct {
 	Int32   int32
 	Int64   int64
@@ -204,7 +229,7 @@ var basic = basicTypes{
 }
 
 func TestBasicTypes(t *testing.T) {
-	setupBasic(newDefault(), setupOpts{})
+	store := setupBasic(newDefault(), setupOpts{})
 	parcel.AddType[basicTypes]()
 
 	path := "testbasictypes"
@@ -214,7 +239,7 @@ func TestBasicTypes(t *testing.T) {
 	assert.NoError(t, err)
 
 	p := parcel.NewParcel()
-	setupBasic(p, setupOpts{NoEraseStore: true})
+	setupBasic(p, setupOpts{Store: store})
 	p.AddType(&basicTypes{})
 
 	loadedA, err := p.Load(&basicTypes{}, path)
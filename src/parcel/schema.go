@@ -0,0 +1,219 @@
+package parcel
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// JSONSchemaURI is the draft that generated schemas follow.
+const JSONSchemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns a JSON Schema for asset files holding T, including
+// the Type and Parent header.  The schema follows the rules the codec
+// saves with: struct fields that point to other assets are paths, byte
+// slices are unpadded base64 and large integers may be strings.
+func (p *Parcel) JSONSchema(T any) ([]byte, error) {
+	return p.schemaFor([]reflect.Type{reflect.TypeOf(T)})
+}
+
+// JSONSchemaForTypes returns a JSON Schema that accepts assets of any
+// of the named types, as written in the Type header.  With no names
+// every registered type is included.
+func (p *Parcel) JSONSchemaForTypes(names ...string) ([]byte, error) {
+	if len(names) == 0 {
+		names = p.TypeNames()
+		if len(names) == 0 {
+			return nil, fmt.Errorf("%w: no types are registered", ErrUnknownType)
+		}
+	}
+	types := make([]reflect.Type, 0, len(names))
+	for _, name := range names {
+		typ, ok := p.typeByName(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownType, name)
+		}
+		types = append(types, typ)
+	}
+	return p.schemaFor(types)
+}
+
+func (p *Parcel) schemaFor(types []reflect.Type) ([]byte, error) {
+	g := &schemaGen{p: p, defs: map[string]any{}, names: map[reflect.Type]string{}}
+	var files []any
+	for _, typ := range types {
+		if typ == nil || !isPointer(typ) {
+			return nil, fmt.Errorf("%w: schemas are made for pointers to structs, not %v", ErrInvalidType, typ)
+		}
+		if err := p.checkType(typ, false); err != nil {
+			return nil, err
+		}
+		obj := g.value(typ)
+		if !planFor(typ).custom {
+			obj = g.value(typ.Elem())
+		}
+		files = append(files, map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"Type":   map[string]any{"const": typeStr(typ)},
+				"Parent": map[string]any{"type": "string"},
+				"Obj":    obj,
+			},
+			"required": []string{"Type", "Obj"},
+		})
+	}
+	schema := map[string]any{"$schema": JSONSchemaURI}
+	if len(files) == 1 {
+		for k, v := range files[0].(map[string]any) {
+			schema[k] = v
+		}
+	} else {
+		schema["oneOf"] = files
+	}
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+type schemaGen struct {
+	p     *Parcel
+	defs  map[string]any
+	names map[reflect.Type]string
+}
+
+// reference is the schema of a path to another asset.
+func (g *schemaGen) reference() map[string]any {
+	exts := g.p.Extensions()
+	if g.p.ext == "" {
+		return map[string]any{"type": "string", "minLength": 1, "description": "path of an asset"}
+	}
+	for i, ext := range exts {
+		exts[i] = regexp.QuoteMeta(ext)
+	}
+	return map[string]any{
+		"type":        "string",
+		"pattern":     "(" + strings.Join(exts, "|") + ")$",
+		"description": "path of an asset",
+	}
+}
+
+// field is the schema of a struct field of typ.  Only struct fields are
+// written as references.
+func (g *schemaGen) field(typ reflect.Type) any {
+	switch {
+	case typ.Kind() == reflect.Interface && !typ.Implements(customSaveLoader):
+		return map[string]any{"anyOf": []any{g.reference(), map[string]any{"type": "object"}}}
+	case isPointer(typ) && !planFor(typ).custom:
+		return map[string]any{"anyOf": []any{g.reference(), g.value(typ.Elem())}}
+	}
+	return g.value(typ)
+}
+
+// value is the schema of a value of typ written in place.
+func (g *schemaGen) value(typ reflect.Type) any {
+	if planFor(typ).custom {
+		return map[string]any{"description": "saved by " + typ.String()}
+	}
+	switch typ.Kind() {
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.Pointer:
+		return map[string]any{"anyOf": []any{map[string]any{"type": "null"}, g.value(typ.Elem())}}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		bits := typ.Bits()
+		return map[string]any{"type": "integer", "minimum": -(int64(1) << (bits - 1)), "maximum": int64(1)<<(bits-1) - 1}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "minimum": 0, "maximum": uint64(1)<<typ.Bits() - 1}
+	case reflect.Int, reflect.Int64:
+		// integers that do not fit in a float64 are written as strings
+		return map[string]any{"type": []string{"integer", "string"}, "pattern": "^-?[0-9]+$"}
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"type": []string{"integer", "string"}, "minimum": 0, "pattern": "^[0-9]+$"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem() == reflect.TypeFor[byte]() {
+			return map[string]any{"type": "string", "contentEncoding": "base64", "pattern": "^[A-Za-z0-9+/]*$"}
+		}
+		s := map[string]any{"type": "array", "items": g.value(typ.Elem())}
+		if typ.Kind() == reflect.Array {
+			s["maxItems"] = typ.Len()
+		}
+		return s
+	case reflect.Map:
+		s := map[string]any{"type": "object", "additionalProperties": g.value(typ.Elem())}
+		if names := g.keyNames(typ.Key()); names != nil {
+			s["propertyNames"] = names
+		}
+		return s
+	case reflect.Struct:
+		return map[string]any{"$ref": "#/$defs/" + g.define(typ)}
+	}
+	return map[string]any{}
+}
+
+// keyNames is the schema of the names of map keys of typ, or nil if any
+// string is allowed.
+func (g *schemaGen) keyNames(typ reflect.Type) any {
+	if typ.Kind() == reflect.String || typ.Implements(textMarshaler) {
+		return nil
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"pattern": "^-?[0-9]+$"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"pattern": "^[0-9]+$"}
+	case reflect.Bool:
+		return map[string]any{"enum": []string{"false", "true"}}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"pattern": `^-?[0-9.]+([eE][-+]?[0-9]+)?$|^[-+]Inf$|^NaN$`}
+	case reflect.Pointer:
+		// nil keys are written as ""
+		return map[string]any{"anyOf": []any{g.reference(), map[string]any{"const": ""}}}
+	}
+	return nil
+}
+
+var defNameReplacer = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// define adds the schema of the struct typ to the definitions and
+// returns its name.
+func (g *schemaGen) define(typ reflect.Type) string {
+	if name, ok := g.names[typ]; ok {
+		return name
+	}
+	name := defNameReplacer.ReplaceAllString(typ.String(), "_")
+	for taken := true; taken; {
+		_, taken = g.defs[name]
+		if taken {
+			name += "_"
+		}
+	}
+	g.names[typ] = name
+	g.defs[name] = nil // reserve the name while fields are generated
+
+	props := map[string]any{}
+	var required []string
+	for _, f := range planFor(typ).fields {
+		props[f.name] = g.field(f.typ)
+		if f.required {
+			required = append(required, f.name)
+		}
+	}
+	def := map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+	if required != nil {
+		def["required"] = required
+	}
+	g.defs[name] = def
+	return name
+}
@@ -0,0 +1,65 @@
+package parcel_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaAsset struct {
+	Name    string
+	Small   int8
+	Big     uint64
+	Data    []byte
+	Owner   *testType
+	Any     any
+	List    []*schemaAsset
+	ByID    map[int]string
+	ByOwner map[*testType]bool
+	Fixed   [2]float32
+	hidden  int
+}
+
+func TestJSONSchema(t *testing.T) {
+	p := parcel.NewParcel()
+	setupBasic(p, setupOpts{})
+	p.AddType(&schemaAsset{})
+	p.RegisterCodec(".yaml", &parcel.YAMLCodec{})
+
+	data, err := p.JSONSchema(&schemaAsset{})
+	assert.NoError(t, err)
+	var schema map[string]any
+	assert.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, parcel.JSONSchemaURI, schema["$schema"])
+	assert.Equal(t, []any{"Type", "Obj"}, schema["required"])
+
+	props := schema["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"const": "*parcel_test.schemaAsset"}, props["Type"])
+	assert.Equal(t, map[string]any{"$ref": "#/$defs/parcel_test.schemaAsset"}, props["Obj"])
+
+	defs := schema["$defs"].(map[string]any)
+	fields := defs["parcel_test.schemaAsset"].(map[string]any)["properties"].(map[string]any)
+	reference := map[string]any{"type": "string", "pattern": `(\.parcel|\.yaml)$`, "description": "path of an asset"}
+	assert.NotContains(t, fields, "hidden")
+	assert.Equal(t, map[string]any{"type": "integer", "minimum": -128.0, "maximum": 127.0}, fields["Small"])
+	assert.Equal(t, []any{"integer", "string"}, fields["Big"].(map[string]any)["type"])
+	assert.Equal(t, "base64", fields["Data"].(map[string]any)["contentEncoding"])
+	assert.Equal(t, map[string]any{"anyOf": []any{reference, map[string]any{"$ref": "#/$defs/parcel_test.testType"}}}, fields["Owner"])
+	assert.Equal(t, map[string]any{"anyOf": []any{reference, map[string]any{"type": "object"}}}, fields["Any"])
+	assert.Equal(t, map[string]any{"anyOf": []any{map[string]any{"type": "null"}, map[string]any{"$ref": "#/$defs/parcel_test.schemaAsset"}}},
+		fields["List"].(map[string]any)["items"], "slice elements are written inline")
+	assert.Equal(t, map[string]any{"pattern": "^-?[0-9]+$"}, fields["ByID"].(map[string]any)["propertyNames"])
+	assert.Equal(t, map[string]any{"anyOf": []any{reference, map[string]any{"const": ""}}}, fields["ByOwner"].(map[string]any)["propertyNames"])
+	assert.Equal(t, 2.0, fields["Fixed"].(map[string]any)["maxItems"])
+	assert.Contains(t, defs, "parcel_test.testType")
+
+	_, err = p.JSONSchema(schemaAsset{})
+	assert.ErrorIs(t, err, parcel.ErrInvalidType)
+	_, err = p.JSONSchemaForTypes("*nope.T")
+	assert.ErrorIs(t, err, parcel.ErrUnknownType)
+	data, err = p.JSONSchemaForTypes()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"oneOf"`)
+}
@@ -0,0 +1,95 @@
+package parcel
+
+import (
+	"cmp"
+	"expvar"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Stats is a snapshot of what a Parcel has loaded.
+type Stats struct {
+	// LoadedByType counts the loaded assets of each type.
+	LoadedByType map[string]int
+	// BytesReadByFS counts the bytes read from each registered fs.FS.
+	BytesReadByFS map[string]int64
+	// LoadTime is how long each asset took to load, including the
+	// assets it references that were not already loaded.
+	LoadTime map[string]time.Duration
+	// CacheHits and CacheMisses count Loads that did and did not find
+	// the asset already loaded.
+	CacheHits   int
+	CacheMisses int
+	// LoadableTypes is the number of save format types that have been
+	// built and cached.
+	LoadableTypes int
+}
+
+type statsCounters struct {
+	bytesRead map[string]int64
+	loadTime  map[string]time.Duration
+	hits      int
+	misses    int
+}
+
+func newStatsCounters() statsCounters {
+	return statsCounters{
+		bytesRead: make(map[string]int64),
+		loadTime:  make(map[string]time.Duration),
+	}
+}
+
+func (p *Parcel) Stats() Stats {
+	s := Stats{
+		LoadedByType:  make(map[string]int),
+		BytesReadByFS: maps.Clone(p.stats.bytesRead),
+		LoadTime:      maps.Clone(p.stats.loadTime),
+		CacheHits:     p.stats.hits,
+		CacheMisses:   p.stats.misses,
+		LoadableTypes: len(p.loadableTypes),
+	}
+	for _, obj := range p.loadedObjects() {
+		s.LoadedByType[typeStr(reflect.TypeOf(obj))]++
+	}
+	return s
+}
+
+// ResetStats clears the counters reported by Stats.  Loaded assets are
+// not affected.
+func (p *Parcel) ResetStats() {
+	p.stats = newStatsCounters()
+}
+
+// PublishExpvar publishes the Parcel's Stats as the expvar name.  Like
+// expvar.Publish, it panics if name is already in use.
+func (p *Parcel) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return p.Stats() }))
+}
+
+// String dumps the stats as text, with the slowest loads first.
+func (s Stats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cache: %d hits, %d misses\n", s.CacheHits, s.CacheMisses)
+	fmt.Fprintf(&b, "loadable types: %d\n", s.LoadableTypes)
+	b.WriteString("loaded by type:\n")
+	for _, typ := range slices.Sorted(maps.Keys(s.LoadedByType)) {
+		fmt.Fprintf(&b, "  %s: %d\n", typ, s.LoadedByType[typ])
+	}
+	b.WriteString("bytes read by fs:\n")
+	for _, name := range slices.Sorted(maps.Keys(s.BytesReadByFS)) {
+		fmt.Fprintf(&b, "  %s: %d\n", name, s.BytesReadByFS[name])
+	}
+	b.WriteString("load time:\n")
+	paths := slices.Collect(maps.Keys(s.LoadTime))
+	slices.SortFunc(paths, func(a, b string) int {
+		return cmp.Or(cmp.Compare(s.LoadTime[b], s.LoadTime[a]), strings.Compare(a, b))
+	})
+	for _, path := range paths {
+		fmt.Fprintf(&b, "  %s: %v\n", path, s.LoadTime[path])
+	}
+	return b.String()
+}
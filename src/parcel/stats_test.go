@@ -0,0 +1,41 @@
+package parcel_test
+
+import (
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	linked := &testType{String: "linked"}
+	p.SetSavePath(linked, "linked")
+	p.SetSavePath(&testType{String: "main", OtherObj: linked}, "main")
+
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	_, err := p2.Load(&testType{}, "main")
+	assert.NoError(t, err)
+	_, err = p2.Load(&testType{}, "linked")
+	assert.NoError(t, err)
+
+	s := p2.Stats()
+	assert.Equal(t, map[string]int{"*parcel_test.testType": 2}, s.LoadedByType)
+	assert.Equal(t, 1, s.CacheHits, "linked was loaded as a reference of main")
+	assert.Equal(t, 2, s.CacheMisses)
+	assert.Equal(t, 1, s.LoadableTypes)
+	assert.Len(t, s.LoadTime, 2)
+	assert.GreaterOrEqual(t, s.LoadTime["main.parcel"], s.LoadTime["linked.parcel"])
+
+	mainData, _ := store.ReadFile("main.parcel")
+	linkedData, _ := store.ReadFile("linked.parcel")
+	assert.Equal(t, map[string]int64{
+		"#0 *parcel.MemoryFS (priority 0)": int64(len(mainData) + len(linkedData)),
+	}, s.BytesReadByFS)
+	assert.Contains(t, s.String(), "cache: 1 hits, 2 misses")
+
+	p2.ResetStats()
+	assert.Zero(t, p2.Stats().CacheMisses)
+}
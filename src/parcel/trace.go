@@ -0,0 +1,107 @@
+package parcel
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// Operations reported to a Tracer and the Logger.
+const (
+	OpLoad     = "load"
+	OpReadFile = "readfile"
+	OpResolve  = "resolve"
+	OpSave     = "save"
+	OpCreate   = "create"
+)
+
+// SpanInfo describes an operation reported to a Tracer.
+type SpanInfo struct {
+	Op   string
+	Path string
+	Type string
+	// FS names the filesystem probed by OpReadFile.
+	FS string
+	// ReferencedBy is the chain of assets being loaded when the
+	// operation started, outermost first.
+	ReferencedBy []string
+}
+
+// Tracer is notified around each load, filesystem probe, reference
+// resolution, save and factory invocation.
+type Tracer interface {
+	// StartSpan is called as an operation begins.  The returned
+	// function is called with the operation's result when it ends.
+	StartSpan(info SpanInfo) func(err error)
+}
+
+// SetLogger sets a logger that receives a record for every traced
+// operation.  Successful operations and filesystem probes are logged at
+// debug level, failures at error level.  A nil logger disables logging.
+func (p *Parcel) SetLogger(logger *slog.Logger) {
+	p.logger = logger
+}
+
+// SetTracer sets the Tracer for the Parcel, nil disables tracing.
+func (p *Parcel) SetTracer(tracer Tracer) {
+	p.tracer = tracer
+}
+
+func noopSpanEnd(error) {}
+
+// trace starts a span for op.  The returned function must be called
+// when the operation ends.
+func (p *Parcel) trace(op string, path string, typ reflect.Type, fsName string) func(error) {
+	if p.tracer == nil && p.logger == nil {
+		return noopSpanEnd
+	}
+	info := SpanInfo{
+		Op:           op,
+		Path:         path,
+		FS:           fsName,
+		ReferencedBy: slices.Clone(p.loadStack),
+	}
+	if typ != nil {
+		info.Type = typeStr(typ)
+	}
+	var end func(error)
+	if p.tracer != nil {
+		end = p.tracer.StartSpan(info)
+	}
+	start := time.Now()
+	return func(err error) {
+		if p.logger != nil {
+			p.logSpan(info, time.Since(start), err)
+		}
+		if end != nil {
+			end(err)
+		}
+	}
+}
+
+func (p *Parcel) logSpan(info SpanInfo, elapsed time.Duration, err error) {
+	level := slog.LevelDebug
+	attrs := make([]slog.Attr, 0, 6)
+	if info.Path != "" {
+		attrs = append(attrs, slog.String("path", info.Path))
+	}
+	if info.Type != "" {
+		attrs = append(attrs, slog.String("type", info.Type))
+	}
+	if info.FS != "" {
+		attrs = append(attrs, slog.String("fs", info.FS))
+	}
+	if len(info.ReferencedBy) > 0 {
+		attrs = append(attrs, slog.Any("referencedBy", info.ReferencedBy))
+	}
+	attrs = append(attrs, slog.Duration("elapsed", elapsed))
+	if err != nil {
+		if info.Op != OpReadFile {
+			level = slog.LevelError
+		}
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	p.logger.LogAttrs(context.Background(), level, "parcel "+info.Op, attrs...)
+}
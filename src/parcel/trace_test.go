@@ -0,0 +1,67 @@
+package parcel_test
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingTracer struct {
+	spans []string
+}
+
+func (r *recordingTracer) StartSpan(info parcel.SpanInfo) func(error) {
+	return func(err error) {
+		r.spans = append(r.spans, fmt.Sprintf("%s %s %s %v ok=%v", info.Op, info.Path, info.Type, info.ReferencedBy, err == nil))
+	}
+}
+
+func TestTracer(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	linked := &testType{String: "linked"}
+	p.SetSavePath(linked, "linked")
+	p.SetSavePath(&testType{String: "main", OtherObj: linked}, "main")
+
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	tracer := &recordingTracer{}
+	p2.SetTracer(tracer)
+	_, err := p2.Load(&testType{}, "main")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"readfile main.parcel  [] ok=true",
+		"create  *parcel_test.testType [] ok=true",
+		"readfile linked.parcel  [main.parcel] ok=true",
+		"create  *parcel_test.testType [main.parcel] ok=true",
+		"load linked.parcel *parcel_test.testType [main.parcel] ok=true",
+		"resolve linked.parcel *parcel_test.testType [main.parcel] ok=true",
+		"load main.parcel *parcel_test.testType [] ok=true",
+	}, tracer.spans)
+}
+
+func TestLoggerAndReferenceChain(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	missing := &testType{String: "missing"}
+	middle := &testType{String: "middle", OtherObj: missing}
+	p.SetSavePath(missing, "missing")
+	p.SetSavePath(middle, "middle")
+	p.SetSavePath(&testType{String: "top", OtherObj: middle}, "top")
+	store.DeleteFile("missing.parcel")
+
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	var logs bytes.Buffer
+	p2.SetLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelError})))
+
+	_, err := p2.Load(&testType{}, "top")
+	assert.EqualError(t, err, "read missing.parcel: asset not found: file does not exist "+
+		"(referenced by top.parcel > middle.parcel)")
+	assert.Contains(t, logs.String(), `msg="parcel load" path=missing.parcel type=*parcel_test.testType referencedBy="[top.parcel middle.parcel]"`)
+}
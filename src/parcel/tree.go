@@ -0,0 +1,191 @@
+package parcel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/launchdarkly/go-jsonstream/v3/jwriter"
+)
+
+// node is a generic view of serialized data that keeps object keys in
+// their original order and numbers as their original text.  It is used
+// where assets are compared or combined without going through Go types.
+type node struct {
+	kind   nodeKind
+	value  any // nil, bool, json.Number or string for scalars
+	keys   []string
+	fields map[string]*node
+	elems  []*node
+}
+
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota
+	objectNode
+	arrayNode
+)
+
+func parseNode(data []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	n, err := decodeNode(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the end of the top level value")
+	}
+	return n, nil
+}
+
+func decodeNode(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		n := &node{kind: objectNode, fields: map[string]*node{}}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+			child, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := n.fields[key]; !dup {
+				n.keys = append(n.keys, key)
+			}
+			n.fields[key] = child
+		}
+		_, err := dec.Token()
+		return n, err
+	case json.Delim('['):
+		n := &node{kind: arrayNode}
+		for dec.More() {
+			child, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.elems = append(n.elems, child)
+		}
+		_, err := dec.Token()
+		return n, err
+	}
+	return &node{kind: scalarNode, value: tok}, nil
+}
+
+func (n *node) write(w *jwriter.Writer) {
+	switch n.kind {
+	case objectNode:
+		obj := w.Object()
+		for _, k := range n.keys {
+			n.fields[k].write(obj.Name(k))
+		}
+		obj.End()
+	case arrayNode:
+		arr := w.Array()
+		for _, e := range n.elems {
+			elemW := jwriter.NewWriter()
+			e.write(&elemW)
+			arr.Raw(elemW.Bytes())
+		}
+		arr.End()
+	default:
+		switch v := n.value.(type) {
+		case nil:
+			w.Null()
+		case bool:
+			w.Bool(v)
+		case json.Number:
+			w.Raw([]byte(v))
+		case string:
+			w.String(v)
+		}
+	}
+}
+
+func (n *node) bytes() []byte {
+	w := jwriter.NewWriter()
+	n.write(&w)
+	return w.Bytes()
+}
+
+// equal reports whether two nodes hold the same data.  A nil node
+// represents an absent value and is only equal to another nil node.
+func (n *node) equal(o *node) bool {
+	if n == nil || o == nil {
+		return n == o
+	}
+	if n.kind != o.kind {
+		return false
+	}
+	switch n.kind {
+	case objectNode:
+		if len(n.fields) != len(o.fields) {
+			return false
+		}
+		for k, v := range n.fields {
+			if !v.equal(o.fields[k]) {
+				return false
+			}
+		}
+		return true
+	case arrayNode:
+		if len(n.elems) != len(o.elems) {
+			return false
+		}
+		for i := range n.elems {
+			if !n.elems[i].equal(o.elems[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return n.value == o.value
+}
+
+// field returns the named child of an object node, or nil.
+func (n *node) field(name string) *node {
+	if n == nil || n.kind != objectNode {
+		return nil
+	}
+	return n.fields[name]
+}
+
+// elem returns the i'th child of an array node, or nil.
+func (n *node) elem(i int) *node {
+	if n == nil || n.kind != arrayNode || i >= len(n.elems) {
+		return nil
+	}
+	return n.elems[i]
+}
+
+// toAny converts the node into plain Go values, objects become
+// map[string]any, arrays []any and numbers json.Number.
+func (n *node) toAny() any {
+	if n == nil {
+		return nil
+	}
+	switch n.kind {
+	case objectNode:
+		m := make(map[string]any, len(n.fields))
+		for k, v := range n.fields {
+			m[k] = v.toAny()
+		}
+		return m
+	case arrayNode:
+		a := make([]any, len(n.elems))
+		for i, e := range n.elems {
+			a[i] = e.toAny()
+		}
+		return a
+	}
+	return n.value
+}
@@ -0,0 +1,255 @@
+package parcel
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"reflect"
+)
+
+// Txn buffers Save, SetSavePath, Delete and Move calls so that they
+// can be applied as a single unit.  Nothing is written until Commit.
+// If any change fails part way through Commit, every file that was
+// touched is restored and the Parcel's identity maps and codec state
+// are put back to the way they were before the transaction started.
+type Txn struct {
+	p    *Parcel
+	ops  []txnOp
+	done bool
+}
+
+type txnOpKind int
+
+const (
+	txnSave txnOpKind = iota
+	txnSetSavePath
+	txnDelete
+	txnMove
+)
+
+type txnOp struct {
+	kind txnOpKind
+	obj  any
+	path string
+	to   string
+}
+
+type fileBackup struct {
+	path       string
+	data       []byte
+	existed    bool
+	codecState any
+}
+
+var errTxnDone = errors.New("transaction has already been committed or rolled back")
+
+// Begin starts a new transaction.  Rolling back needs the previous
+// contents of each file, so Commit requires the registered WritableFS
+// to also implement fs.FS.
+func (p *Parcel) Begin() *Txn {
+	return &Txn{p: p}
+}
+
+func (t *Txn) Save(T any) error {
+	return t.add(txnOp{kind: txnSave, obj: T})
+}
+
+func (t *Txn) SetSavePath(T any, path string) error {
+	return t.add(txnOp{kind: txnSetSavePath, obj: T, path: t.p.normPath(path)})
+}
+
+func (t *Txn) Delete(path string) error {
+	return t.add(txnOp{kind: txnDelete, path: t.p.normPath(path)})
+}
+
+func (t *Txn) Move(from string, to string) error {
+	return t.add(txnOp{kind: txnMove, path: t.p.normPath(from), to: t.p.normPath(to)})
+}
+
+func (t *Txn) add(op txnOp) error {
+	if t.done {
+		return errTxnDone
+	}
+	t.ops = append(t.ops, op)
+	return nil
+}
+
+// Rollback discards all buffered changes.  Calling Rollback after
+// Commit does nothing, so it is safe to defer.
+func (t *Txn) Rollback() {
+	t.done = true
+	t.ops = nil
+}
+
+// Commit validates every buffered change and then applies them in
+// order.  If validation fails nothing is written.  If applying a change
+// fails, all changes made so far are undone.
+func (t *Txn) Commit() error {
+	if t.done {
+		return errTxnDone
+	}
+	t.done = true
+	if err := t.validate(); err != nil {
+		return err
+	}
+
+	p := t.p
+	readable := p.writefs.(fs.FS)
+	objectFromPath := maps.Clone(p.objectFromPath)
+	pathFromObject := maps.Clone(p.pathFromObject)
+	cleanHash := maps.Clone(p.cleanHash)
+	extras := maps.Clone(p.extras)
+
+	var backups []fileBackup
+	seen := map[string]bool{}
+	backup := func(path string) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		data, err := fs.ReadFile(readable, path)
+		backups = append(backups, fileBackup{path: path, data: data, existed: err == nil, codecState: p.codecState(path)})
+	}
+
+	for _, op := range t.ops {
+		var err error
+		switch op.kind {
+		case txnSave:
+			path, _ := p.pathOf(op.obj)
+			backup(path)
+			err = p.Save(op.obj)
+		case txnSetSavePath:
+			backup(op.path)
+			err = p.SetSavePath(op.obj, op.path)
+		case txnDelete:
+			backup(op.path)
+			err = p.Delete(op.path)
+		case txnMove:
+			backup(op.path)
+			backup(op.to)
+			err = p.Move(op.path, op.to)
+		}
+		if err != nil {
+			restoreErr := t.restore(readable, backups)
+			p.objectFromPath = objectFromPath
+			p.pathFromObject = pathFromObject
+			p.cleanHash = cleanHash
+			p.extras = extras
+			return errors.Join(fmt.Errorf("transaction rolled back: %w", err), restoreErr)
+		}
+	}
+	return nil
+}
+
+func (t *Txn) restore(readable fs.FS, backups []fileBackup) error {
+	var errs []error
+	for i := len(backups) - 1; i >= 0; i-- {
+		b := backups[i]
+		t.p.setCodecState(b.path, b.codecState)
+		if b.existed {
+			errs = append(errs, t.p.writefs.WriteFile(b.path, b.data))
+		} else if _, err := fs.Stat(readable, b.path); err == nil {
+			errs = append(errs, t.p.writefs.DeleteFile(b.path))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validate simulates the buffered changes against the current state so
+// that obvious mistakes are caught before anything is written.
+func (t *Txn) validate() error {
+	p := t.p
+	if p.writefs == nil {
+		return ErrNoWritableFS
+	}
+	readable, ok := p.writefs.(fs.FS)
+	if !ok {
+		return fmt.Errorf("transactions require a WritableFS that also implements fs.FS")
+	}
+
+	// overlays on top of the Parcel's state, a nil/false entry means removed
+	loaded := map[string]any{}
+	files := map[string]bool{}
+	pathOf := map[any]string{}
+
+	isLoaded := func(path string) bool {
+		if obj, ok := loaded[path]; ok {
+			return obj != nil
+		}
+		_, ok := p.objectAt(path)
+		return ok
+	}
+	inWritable := func(path string) bool {
+		if exists, ok := files[path]; ok {
+			return exists
+		}
+		_, err := fs.Stat(readable, path)
+		return err == nil
+	}
+	exists := func(path string) bool {
+		if exists, ok := files[path]; ok {
+			return exists || isLoaded(path)
+		}
+		return isLoaded(path) || p.exists(path)
+	}
+	savePath := func(obj any) (string, bool) {
+		if path, ok := pathOf[obj]; ok {
+			return path, path != ""
+		}
+		return p.pathOf(obj)
+	}
+	objectAt := func(path string) any {
+		if obj, ok := loaded[path]; ok {
+			return obj
+		}
+		obj, _ := p.objectAt(path)
+		return obj
+	}
+
+	for _, op := range t.ops {
+		switch op.kind {
+		case txnSave:
+			path, ok := savePath(op.obj)
+			if !ok {
+				return p.newError("save", "", reflect.TypeOf(op.obj), ErrNoSavePath)
+			}
+			loaded[path] = op.obj
+			files[path] = true
+
+		case txnSetSavePath:
+			if isLoaded(op.path) {
+				return p.newError("setsavepath", op.path, reflect.TypeOf(op.obj), ErrPathExists)
+			}
+			pathOf[op.obj] = op.path
+			loaded[op.path] = op.obj
+			files[op.path] = true
+
+		case txnDelete:
+			if !inWritable(op.path) {
+				return p.newError("delete", op.path, nil, ErrNotFound)
+			}
+			if obj := objectAt(op.path); obj != nil {
+				pathOf[obj] = ""
+			}
+			loaded[op.path] = nil
+			files[op.path] = false
+
+		case txnMove:
+			if !exists(op.path) {
+				return p.newError("move", op.path, nil, ErrNotFound)
+			}
+			if exists(op.to) {
+				return p.newError("move", op.to, nil, ErrPathExists)
+			}
+			if obj := objectAt(op.path); obj != nil {
+				pathOf[obj] = op.to
+				loaded[op.to] = obj
+			}
+			loaded[op.path] = nil
+			files[op.path] = false
+			files[op.to] = true
+		}
+	}
+	return nil
+}
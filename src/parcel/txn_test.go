@@ -0,0 +1,172 @@
+package parcel_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingFS fails any write to failPath
+type failingFS struct {
+	*parcel.MemoryFS
+	failPath string
+}
+
+func (f *failingFS) WriteFile(path string, data []byte) error {
+	if path == f.failPath {
+		return errors.New("disk full")
+	}
+	return f.MemoryFS.WriteFile(path, data)
+}
+
+func TestTxnCommit(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+
+	a := &testType{String: "a"}
+	b := &testType{String: "b"}
+	assert.NoError(t, p.SetSavePath(a, "a"))
+
+	txn := p.Begin()
+	defer txn.Rollback()
+	a.OtherObj = b
+	txn.SetSavePath(b, "b")
+	txn.Save(a)
+	txn.Move("a", "moved")
+	_, err := store.ReadFile("b.parcel")
+	assert.ErrorIs(t, err, fs.ErrNotExist, "nothing is written before Commit")
+
+	assert.NoError(t, txn.Commit())
+	_, err = store.ReadFile("b.parcel")
+	assert.NoError(t, err)
+	_, err = store.ReadFile("a.parcel")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	loaded, err := p.Load(&testType{}, "moved")
+	assert.NoError(t, err)
+	assert.True(t, loaded == a, "moved objects keep their identity")
+
+	assert.Error(t, txn.Commit(), "cannot commit twice")
+}
+
+func TestTxnValidation(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	a := &testType{String: "a"}
+	assert.NoError(t, p.SetSavePath(a, "a"))
+
+	txn := p.Begin()
+	txn.SetSavePath(&testType{}, "new")
+	txn.Save(&testType{}) // no save path
+	assert.Error(t, txn.Commit())
+	_, err := store.ReadFile("new.parcel")
+	assert.ErrorIs(t, err, fs.ErrNotExist, "invalid transactions write nothing")
+
+	txn = p.Begin()
+	txn.Delete("a")
+	txn.Delete("a")
+	assert.Error(t, txn.Commit(), "cannot delete twice")
+
+	txn = p.Begin()
+	txn.Move("a", "b")
+	txn.SetSavePath(&testType{}, "b")
+	assert.Error(t, txn.Commit(), "move destination is taken")
+}
+
+func TestTxnRollback(t *testing.T) {
+	p := parcel.NewParcel()
+	store := &failingFS{MemoryFS: parcel.NewMemoryFS(), failPath: "fails.parcel"}
+	p.RegisterFS(store, 0)
+	p.RegisterWriteableFS(store)
+	p.AddType(&testType{})
+
+	a := &testType{String: "a"}
+	assert.NoError(t, p.SetSavePath(a, "a"))
+	before, _ := store.ReadFile("a.parcel")
+
+	txn := p.Begin()
+	a.String = "changed"
+	txn.Save(a)
+	txn.SetSavePath(&testType{}, "created")
+	txn.Move("a", "fails")
+	err := txn.Commit()
+	assert.ErrorContains(t, err, "disk full")
+
+	after, err := store.ReadFile("a.parcel")
+	assert.NoError(t, err)
+	assert.Equal(t, string(before), string(after), "files are restored")
+	_, err = store.ReadFile("created.parcel")
+	assert.ErrorIs(t, err, fs.ErrNotExist, "new files are removed")
+
+	loaded, err := p.Load(&testType{}, "a")
+	assert.NoError(t, err)
+	assert.True(t, loaded == a, "identity maps are restored")
+	_, err = p.Load(&testType{}, "created")
+	assert.Error(t, err)
+}
+
+func TestTxnRollbackKeepsCodecState(t *testing.T) {
+	p := parcel.NewParcel()
+	store := &failingFS{MemoryFS: parcel.NewMemoryFS(), failPath: "fails.yaml"}
+	p.RegisterFS(store, 0)
+	p.RegisterWriteableFS(store)
+	p.RegisterCodec(".yaml", &parcel.YAMLCodec{})
+	p.AddType(&testType{})
+	store.WriteFile("a.yaml", []byte(`Type: '*parcel_test.testType'
+Obj:
+  String: a # the name
+`))
+	obj, err := p.Load(&testType{}, "a.yaml")
+	assert.NoError(t, err)
+
+	txn := p.Begin()
+	txn.Move("a.yaml", "b.yaml")
+	txn.Move("b.yaml", "fails.yaml")
+	assert.ErrorContains(t, txn.Commit(), "disk full")
+
+	assert.NoError(t, p.Save(obj))
+	data, _ := store.ReadFile("a.yaml")
+	assert.Contains(t, string(data), "String: a # the name", "comments are restored")
+	assert.NoError(t, p.SetSavePath(&testType{}, "b.yaml"))
+	data, _ = store.ReadFile("b.yaml")
+	assert.NotContains(t, string(data), "#")
+}
+
+func TestMoveSaveFails(t *testing.T) {
+	p := parcel.NewParcel()
+	store := &failingFS{MemoryFS: parcel.NewMemoryFS(), failPath: "fails.parcel"}
+	p.RegisterFS(store, 0)
+	p.RegisterWriteableFS(store)
+	p.AddType(&testType{})
+
+	a := &testType{String: "a"}
+	assert.NoError(t, p.SetSavePath(a, "a"))
+	assert.ErrorContains(t, p.Move("a", "fails"), "disk full")
+
+	loaded, err := p.Load(&testType{}, "a")
+	assert.NoError(t, err)
+	assert.True(t, loaded == a, "the object stays at its old path")
+	assert.False(t, p.IsDirty(a), "the clean hash is kept")
+	_, err = p.Load(&testType{}, "fails")
+	assert.Error(t, err)
+}
+
+func TestDeleteMove(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	a := &testType{String: "a"}
+	assert.NoError(t, p.SetSavePath(a, "a"))
+
+	assert.NoError(t, p.Move("a", "b"))
+	assert.Error(t, p.Move("a", "b"))
+	_, err := store.ReadFile("b.parcel")
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.Delete("b"))
+	_, err = store.ReadFile("b.parcel")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Error(t, p.Save(a), "deleted objects lose their save path")
+}
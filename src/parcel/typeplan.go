@@ -0,0 +1,207 @@
+package parcel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// typePlan holds what the codec needs to know about a type.  Plans only
+// depend on the type, so they are built once and shared by all Parcels.
+type typePlan struct {
+	custom   bool // implements CustomSaveLoader
+	postLoad bool // implements PostLoader
+	// exported fields of a struct, in save order
+	fields []fieldPlan
+	byName map[string]*fieldPlan
+	// some field, or field of a struct field, has a default or is
+	// required, see applyFieldRules
+	hasRules bool
+
+	typ     reflect.Type
+	encOnce sync.Once
+	enc     encodeFunc
+	decOnce sync.Once
+	dec     decodeFunc
+}
+
+type fieldPlan struct {
+	name  string
+	index []int
+	typ   reflect.Type
+	tags  map[string]string // parsed parcel struct tag
+
+	defaultValue string
+	hasDefault   bool
+	required     bool
+}
+
+var (
+	plans      sync.Map // reflect.Type -> *typePlan
+	postLoader = reflect.TypeFor[PostLoader]()
+)
+
+func planFor(typ reflect.Type) *typePlan {
+	if plan, ok := plans.Load(typ); ok {
+		return plan.(*typePlan)
+	}
+	plan := &typePlan{
+		typ:      typ,
+		custom:   typ.Implements(customSaveLoader),
+		postLoad: typ.Implements(postLoader),
+	}
+	if typ.Kind() == reflect.Struct {
+		for _, field := range reflect.VisibleFields(typ) {
+			if !field.IsExported() {
+				continue
+			}
+			f := fieldPlan{
+				name:  field.Name,
+				index: field.Index,
+				typ:   field.Type,
+				tags:  parseTag(field.Tag.Get("parcel")),
+			}
+			f.defaultValue, f.hasDefault = f.tags["default"]
+			_, f.required = f.tags["required"]
+			// structs cannot contain themselves by value, so this ends
+			plan.hasRules = plan.hasRules || f.hasDefault || f.required ||
+				(field.Type.Kind() == reflect.Struct && planFor(field.Type).hasRules)
+			plan.fields = append(plan.fields, f)
+		}
+		plan.byName = make(map[string]*fieldPlan, len(plan.fields))
+		for i := range plan.fields {
+			plan.byName[plan.fields[i].name] = &plan.fields[i]
+		}
+	}
+	actual, _ := plans.LoadOrStore(typ, plan)
+	return actual.(*typePlan)
+}
+
+// encoder and decoder compile on first use rather than in planFor, so
+// that recursive types only need the plan of each field to exist.
+func (plan *typePlan) encoder() encodeFunc {
+	plan.encOnce.Do(func() { plan.enc = compileEncoder(plan.typ) })
+	return plan.enc
+}
+
+func (plan *typePlan) decoder() decodeFunc {
+	plan.decOnce.Do(func() { plan.dec = compileDecoder(plan.typ) })
+	return plan.dec
+}
+
+// Validate checks every registered type for fields that cannot be saved
+// or loaded.  AddType performs the same checks except for interface
+// fields, which need an implementation to be registered and so can only
+// be checked once all types have been added.
+func (p *Parcel) Validate() error {
+	var errs []error
+	for typ := range p.objectNewFunc {
+		if err := p.checkType(typ, true); err != nil {
+			errs = append(errs, p.newError("validate", "", typ, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkType walks typ and reports every field the codec cannot handle.
+func (p *Parcel) checkType(typ reflect.Type, interfaces bool) error {
+	c := typeChecker{p: p, interfaces: interfaces, seen: map[reflect.Type]bool{}}
+	c.walk(typ, "")
+	return errors.Join(c.errs...)
+}
+
+type typeChecker struct {
+	p          *Parcel
+	interfaces bool
+	seen       map[reflect.Type]bool
+	errs       []error
+}
+
+func (c *typeChecker) fail(path string, err error) {
+	if path != "" {
+		err = fmt.Errorf("field %s: %w", path, err)
+	}
+	c.errs = append(c.errs, err)
+}
+
+func (c *typeChecker) walk(typ reflect.Type, path string) {
+	if c.seen[typ] {
+		return
+	}
+	c.seen[typ] = true
+	if planFor(typ).custom {
+		return
+	}
+	switch typ.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		c.fail(path, fmt.Errorf("%w: %s fields cannot be saved", ErrInvalidType, typ.Kind()))
+
+	case reflect.Interface:
+		if c.interfaces && !c.implemented(typ) {
+			c.fail(path, fmt.Errorf("%w: no registered type implements %s", ErrInvalidType, typ))
+		}
+
+	case reflect.Pointer:
+		c.checkNotInterface(typ.Elem(), path)
+		c.walk(typ.Elem(), path)
+
+	case reflect.Slice, reflect.Array:
+		c.checkNotInterface(typ.Elem(), path)
+		c.walk(typ.Elem(), path+"[]")
+
+	case reflect.Map:
+		if err := checkKeyType(typ.Key()); err != nil {
+			c.fail(path, err)
+		}
+		c.checkNotInterface(typ.Elem(), path)
+		c.walk(typ.Elem(), path+"[]")
+
+	case reflect.Struct:
+		for _, field := range planFor(typ).fields {
+			fieldPath := field.name
+			if path != "" {
+				fieldPath = path + "." + field.name
+			}
+			c.checkRules(field, fieldPath)
+			c.walk(field.typ, fieldPath)
+		}
+	}
+}
+
+// checkNotInterface fails if elem, the element of a container, is an
+// interface.  Elements are saved in place, so their type could not be
+// known when loading.
+func (c *typeChecker) checkNotInterface(elem reflect.Type, path string) {
+	if elem.Kind() == reflect.Interface && !planFor(elem).custom {
+		c.fail(path, fmt.Errorf("%w: %s elements can only be saved in struct fields", ErrInvalidType, elem))
+	}
+}
+
+// checkRules checks that a field's default can be loaded.  Nil pointer
+// and interface fields are left out when saving, so they cannot be told
+// apart from missing fields and cannot have rules.
+func (c *typeChecker) checkRules(field fieldPlan, path string) {
+	if field.hasDefault && field.required {
+		c.fail(path, fmt.Errorf("%w: a required field cannot have a default", ErrInvalidType))
+	}
+	if kind := field.typ.Kind(); (kind == reflect.Pointer || kind == reflect.Interface) && (field.hasDefault || field.required) {
+		c.fail(path, fmt.Errorf("%w: %s fields cannot be required or have a default", ErrInvalidType, kind))
+		return
+	}
+	if !field.hasDefault {
+		return
+	}
+	if err := c.p.setFromTag(reflect.New(field.typ).Elem(), field.defaultValue); err != nil {
+		c.fail(path, fmt.Errorf("%w: bad default %q: %v", ErrInvalidType, field.defaultValue, err))
+	}
+}
+
+func (c *typeChecker) implemented(iface reflect.Type) bool {
+	for typ := range c.p.objectNewFunc {
+		if typ.Implements(iface) {
+			return true
+		}
+	}
+	return false
+}
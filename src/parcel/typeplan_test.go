@@ -0,0 +1,136 @@
+package parcel_test
+
+import (
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+type badFields struct {
+	Name    string
+	Updates chan int
+	OnLoad  func()
+	Phase   complex128
+	Nested  struct{ Keys map[struct{ A int }]int }
+	hidden  chan int
+}
+
+type describer interface {
+	Describe() string
+}
+
+type describedAsset struct {
+	Text string
+}
+
+func (d *describedAsset) Describe() string { return d.Text }
+
+type withInterface struct {
+	Item describer
+}
+
+func TestAddTypeRejectsBadFields(t *testing.T) {
+	p := parcel.NewParcel()
+	err := p.AddType(&badFields{})
+	assert.ErrorIs(t, err, parcel.ErrInvalidType)
+	assert.ErrorContains(t, err, "field Updates: invalid asset type: chan fields cannot be saved")
+	assert.ErrorContains(t, err, "field OnLoad")
+	assert.ErrorContains(t, err, "field Phase")
+	assert.ErrorContains(t, err, "field Nested.Keys")
+	assert.NotContains(t, err.Error(), "hidden")
+
+	_, err = p.New(&badFields{})
+	assert.ErrorIs(t, err, parcel.ErrUnknownType, "rejected types are not registered")
+}
+
+func TestValidateInterfaces(t *testing.T) {
+	p := parcel.NewParcel()
+	assert.NoError(t, p.AddType(&withInterface{}))
+	err := p.Validate()
+	assert.ErrorIs(t, err, parcel.ErrInvalidType)
+	assert.ErrorContains(t, err, "field Item")
+
+	assert.NoError(t, p.AddType(&describedAsset{}))
+	assert.NoError(t, p.Validate())
+}
+
+func TestInterfaceReferences(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&withInterface{})
+	p.AddType(&describedAsset{})
+
+	item := &describedAsset{Text: "a sword"}
+	assert.NoError(t, p.SetSavePath(item, "item"))
+	holder := &withInterface{Item: item}
+	assert.NoError(t, p.SetSavePath(holder, "holder"))
+	data, _ := store.ReadFile("holder.parcel")
+	assert.Contains(t, string(data), `"Item":"item.parcel"`)
+
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	p2.AddType(&withInterface{})
+	p2.AddType(&describedAsset{})
+	loaded, err := p2.Load(&withInterface{}, "holder")
+	assert.NoError(t, err)
+	assert.Equal(t, "a sword", loaded.(*withInterface).Item.Describe())
+
+	// values that are not assets are saved in place
+	assert.NoError(t, p.SetSavePath(&withInterface{Item: &describedAsset{Text: "a shield"}}, "inline"))
+	data, _ = store.ReadFile("inline.parcel")
+	assert.Contains(t, string(data), `"Item":{"Text":"a shield"}`)
+}
+
+func TestInterfaceInlineBaselineFormat(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&describedAsset{})
+	p.AddFactoryForType(&withInterface{}, func() (any, error) {
+		return &withInterface{Item: &describedAsset{}}, nil
+	})
+	// older versions always wrote interface values in place
+	store.WriteFile("old.parcel", []byte(`{"Type":"*parcel_test.withInterface","Obj":{"Item":{"Text":"a bow"}}}`))
+
+	loaded, err := p.Load(&withInterface{}, "old")
+	assert.NoError(t, err)
+	assert.Equal(t, "a bow", loaded.(*withInterface).Item.Describe())
+
+	// without a value to load into the field is left empty
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	p2.AddType(&withInterface{})
+	loaded, err = p2.Load(&withInterface{}, "old")
+	assert.NoError(t, err)
+	assert.Nil(t, loaded.(*withInterface).Item)
+}
+
+type anyHolder struct {
+	Any any
+}
+
+type anyContainers struct {
+	List   []any
+	ByName map[string]describer
+}
+
+func TestInterfaceValuesThatAreNotAssets(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&anyHolder{})
+
+	// a string would load back as a path, so it is refused
+	err := p.SetSavePath(&anyHolder{Any: "hello"}, "h")
+	assert.ErrorIs(t, err, parcel.ErrInvalidType)
+	assert.ErrorContains(t, err, "interface field Any holds a string")
+	assert.ErrorIs(t, p.SetSavePath(&anyHolder{Any: 3}, "n"), parcel.ErrInvalidType)
+
+	store.WriteFile("old.parcel", []byte(`{"Type":"*parcel_test.anyHolder","Obj":{"Any":3}}`))
+	_, err = p.Load(&anyHolder{}, "old")
+	assert.ErrorIs(t, err, parcel.ErrInvalidType, "numbers are not dropped silently")
+
+	err = p.AddType(&anyContainers{})
+	assert.ErrorIs(t, err, parcel.ErrInvalidType)
+	assert.ErrorContains(t, err, "field List: invalid asset type: interface {} elements can only be saved in struct fields")
+	assert.ErrorContains(t, err, "field ByName")
+}
@@ -1,23 +1,31 @@
 package parcel
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 )
 
 type WritableFS interface {
 	WriteFile(path string, data []byte) error
-	//DeleteFile(path string) error
+	DeleteFile(path string) error
 }
 
+// SimpleWritableFS writes to the directory at path.  The returned
+// WritableFS is also an fs.FS that reads from the same directory.
 func SimpleWritableFS(path string) WritableFS {
-	return &writeableFS{base: path}
+	return &writeableFS{FS: os.DirFS(path), base: path}
 }
 
 type writeableFS struct {
+	fs.FS
 	base string
 }
 
 func (w *writeableFS) WriteFile(path string, data []byte) error {
 	return os.WriteFile(filepath.Join(w.base, path), data, 0666)
 }
+
+func (w *writeableFS) DeleteFile(path string) error {
+	return os.Remove(filepath.Join(w.base, path))
+}
@@ -0,0 +1,310 @@
+package parcel
+
+/*
+YAML assets are converted to and from the canonical JSON, so they use
+the same header, references, byte encoding and CustomSaveLoader
+handling as JSON assets.  Converting JSON to YAML and back gives the
+original bytes: object keys keep their order, numbers keep their text
+and strings that look like other YAML types are quoted.
+*/
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLCodec stores assets as YAML.  Register it for the extensions that
+// should be YAML, for example
+//
+//	p.RegisterCodec(".yaml", &parcel.YAMLCodec{})
+//
+// Comments in files the codec has decoded are written back when the same
+// path is encoded, attached to the same keys and list items.
+type YAMLCodec struct {
+	mu       sync.Mutex
+	comments map[string]yamlComments
+}
+
+func (c *YAMLCodec) Encode(path string, canonical []byte) ([]byte, error) {
+	n, err := parseNode(canonical)
+	if err != nil {
+		return nil, err
+	}
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{n.yaml()}}
+	c.mu.Lock()
+	c.comments[path].apply(doc, "")
+	c.mu.Unlock()
+	return encodeYAML(doc)
+}
+
+func (c *YAMLCodec) Decode(path string, data []byte) ([]byte, error) {
+	doc, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	n, err := nodeFromYAML(doc)
+	if err != nil {
+		return nil, err
+	}
+	comments := yamlComments{}
+	comments.collect(doc, "")
+	c.mu.Lock()
+	if c.comments == nil {
+		c.comments = map[string]yamlComments{}
+	}
+	c.comments[path] = comments
+	c.mu.Unlock()
+	return n.bytes(), nil
+}
+
+func (c *YAMLCodec) Forget(path string) {
+	c.mu.Lock()
+	delete(c.comments, path)
+	c.mu.Unlock()
+}
+
+func (c *YAMLCodec) Rename(from string, to string) {
+	c.mu.Lock()
+	if comments, ok := c.comments[from]; ok {
+		delete(c.comments, from)
+		c.comments[to] = comments
+	}
+	c.mu.Unlock()
+}
+
+func (c *YAMLCodec) State(path string) any {
+	c.mu.Lock()
+	comments, ok := c.comments[path]
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return comments
+}
+
+func (c *YAMLCodec) SetState(path string, state any) {
+	c.mu.Lock()
+	if comments, ok := state.(yamlComments); ok {
+		if c.comments == nil {
+			c.comments = map[string]yamlComments{}
+		}
+		c.comments[path] = comments
+	} else {
+		delete(c.comments, path)
+	}
+	c.mu.Unlock()
+}
+
+// yamlComment holds the comments attached to one YAML node.
+type yamlComment struct {
+	head, line, foot string
+}
+
+// yamlComments maps the path of a node to its comments.  Paths join
+// keys and indexes with NUL, key nodes have a trailing NUL.
+type yamlComments map[string]yamlComment
+
+func (c yamlComments) collect(y *yaml.Node, path string) {
+	if y.HeadComment != "" || y.LineComment != "" || y.FootComment != "" {
+		c[path] = yamlComment{head: y.HeadComment, line: y.LineComment, foot: y.FootComment}
+	}
+	switch y.Kind {
+	case yaml.DocumentNode:
+		c.collect(y.Content[0], path+"\x00")
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(y.Content); i += 2 {
+			child := path + "\x00" + y.Content[i].Value
+			c.collect(y.Content[i], child+"\x00")
+			c.collect(y.Content[i+1], child)
+		}
+	case yaml.SequenceNode:
+		for i, e := range y.Content {
+			c.collect(e, path+"\x00"+strconv.Itoa(i))
+		}
+	}
+}
+
+func (c yamlComments) apply(y *yaml.Node, path string) {
+	if len(c) == 0 {
+		return
+	}
+	if comment, ok := c[path]; ok {
+		y.HeadComment, y.LineComment, y.FootComment = comment.head, comment.line, comment.foot
+	}
+	switch y.Kind {
+	case yaml.DocumentNode:
+		c.apply(y.Content[0], path+"\x00")
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(y.Content); i += 2 {
+			child := path + "\x00" + y.Content[i].Value
+			c.apply(y.Content[i], child+"\x00")
+			c.apply(y.Content[i+1], child)
+		}
+	case yaml.SequenceNode:
+		for i, e := range y.Content {
+			c.apply(e, path+"\x00"+strconv.Itoa(i))
+		}
+	}
+}
+
+// JSONToYAML converts a JSON document to YAML.
+func JSONToYAML(data []byte) ([]byte, error) {
+	n, err := parseNode(data)
+	if err != nil {
+		return nil, err
+	}
+	return encodeYAML(n.yaml())
+}
+
+func encodeYAML(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// YAMLToJSON converts a YAML document to compact JSON.
+func YAMLToJSON(data []byte) ([]byte, error) {
+	doc, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	n, err := nodeFromYAML(doc)
+	if err != nil {
+		return nil, err
+	}
+	return n.bytes(), nil
+}
+
+func parseYAML(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		return nil, fmt.Errorf("empty YAML document")
+	}
+	return &doc, nil
+}
+
+func (n *node) yaml() *yaml.Node {
+	switch n.kind {
+	case objectNode:
+		y := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, k := range n.keys {
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}
+			y.Content = append(y.Content, key, n.fields[k].yaml())
+		}
+		return y
+	case arrayNode:
+		y := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, e := range n.elems {
+			y.Content = append(y.Content, e.yaml())
+		}
+		return y
+	}
+	switch v := n.value.(type) {
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	case json.Number:
+		tag := "!!int"
+		if _, err := v.Int64(); err != nil {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: string(v)}
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
+
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+func nodeFromYAML(y *yaml.Node) (*node, error) {
+	switch y.Kind {
+	case yaml.DocumentNode:
+		return nodeFromYAML(y.Content[0])
+	case yaml.AliasNode:
+		return nodeFromYAML(y.Alias)
+	case yaml.MappingNode:
+		n := &node{kind: objectNode, fields: map[string]*node{}}
+		for i := 0; i+1 < len(y.Content); i += 2 {
+			key := y.Content[i].Value
+			child, err := nodeFromYAML(y.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := n.fields[key]; !dup {
+				n.keys = append(n.keys, key)
+			}
+			n.fields[key] = child
+		}
+		return n, nil
+	case yaml.SequenceNode:
+		n := &node{kind: arrayNode}
+		for _, e := range y.Content {
+			child, err := nodeFromYAML(e)
+			if err != nil {
+				return nil, err
+			}
+			n.elems = append(n.elems, child)
+		}
+		return n, nil
+	}
+
+	switch y.ShortTag() {
+	case "!!null":
+		return &node{kind: scalarNode}, nil
+	case "!!bool":
+		var b bool
+		if err := y.Decode(&b); err != nil {
+			return nil, err
+		}
+		return &node{kind: scalarNode, value: b}, nil
+	case "!!int", "!!float":
+		if jsonNumber.MatchString(y.Value) {
+			return &node{kind: scalarNode, value: json.Number(y.Value)}, nil
+		}
+		// other YAML spellings such as 0x1f or 1_000
+		var v any
+		if err := y.Decode(&v); err != nil {
+			return nil, err
+		}
+		var text string
+		switch v := v.(type) {
+		case int:
+			text = strconv.Itoa(v)
+		case int64:
+			text = strconv.FormatInt(v, 10)
+		case uint64:
+			text = strconv.FormatUint(v, 10)
+		case float64:
+			text = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		if !jsonNumber.MatchString(text) {
+			return nil, fmt.Errorf("line %d: %s cannot be represented in JSON", y.Line, y.Value)
+		}
+		return &node{kind: scalarNode, value: json.Number(text)}, nil
+	case "!!binary":
+		// yaml decodes binary into a string holding the raw bytes
+		var b string
+		if err := y.Decode(&b); err != nil {
+			return nil, err
+		}
+		return &node{kind: scalarNode, value: base64.RawStdEncoding.EncodeToString([]byte(b))}, nil
+	}
+	return &node{kind: scalarNode, value: y.Value}, nil
+}
@@ -0,0 +1,169 @@
+package parcel_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/stretchr/testify/assert"
+)
+
+type yamlAsset struct {
+	Name   string
+	Owner  *testType
+	Big    uint64
+	Ratio  float64
+	Flags  map[string]bool
+	Words  []string
+	Data   []byte
+	Nested formatTest
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.AddType(&yamlAsset{})
+	owner := &testType{String: "owner"}
+	p.SetSavePath(owner, "owner")
+
+	obj := &yamlAsset{
+		Name:   "true",
+		Owner:  owner,
+		Big:    math.MaxUint64,
+		Ratio:  0.25,
+		Flags:  map[string]bool{"on": true, "null": false},
+		Words:  []string{"123", "", "multi\nline", "a: b"},
+		Data:   []byte{0, 1, 2, 255},
+		Nested: formatTest{Map: map[string]int{}, IntTo: map[int]string{}, F32: 1.5},
+	}
+	assert.NoError(t, p.SetSavePath(obj, "asset"))
+	jsonData, _ := store.ReadFile("asset.parcel")
+
+	yamlData, err := parcel.JSONToYAML(jsonData)
+	assert.NoError(t, err)
+	back, err := parcel.YAMLToJSON(yamlData)
+	assert.NoError(t, err)
+	assert.Equal(t, string(jsonData), string(back))
+}
+
+func TestYAMLCodec(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.RegisterCodec(".yaml", &parcel.YAMLCodec{})
+	p.AddType(&yamlAsset{})
+	owner := &testType{String: "owner"}
+	p.SetSavePath(owner, "owner")
+
+	assert.NoError(t, p.SetSavePath(&yamlAsset{Name: "sword", Owner: owner, Words: []string{"a"}}, "sword.yaml"))
+	data, _ := store.ReadFile("sword.yaml")
+	assert.Equal(t, `Type: '*parcel_test.yamlAsset'
+Parent: ""
+Obj:
+  Name: sword
+  Owner: owner.parcel
+  Big: 0
+  Ratio: 0
+  Flags: {}
+  Words:
+    - a
+  Data: ""
+  Nested:
+    Name: ""
+    Map: {}
+    IntTo: {}
+    Big: 0
+    Small: 0
+    UBig: 0
+    F32: 0
+`, string(data))
+
+	store.WriteFile("authored.yaml", []byte(`# written by hand
+Type: "*parcel_test.yamlAsset"
+Obj:
+  Name: axe     # the name
+  Owner: owner.parcel
+  Big: 0x10
+  Ratio: 1e3
+  Flags: {on: true, off: false}
+  Data: !!binary AAEC
+`))
+	p2 := parcel.NewParcel()
+	setupBasic(p2, setupOpts{Store: store})
+	p2.RegisterCodec(".yaml", &parcel.YAMLCodec{})
+	p2.AddType(&yamlAsset{})
+	p2.AddType(&testType{})
+	loaded, err := p2.Load(&yamlAsset{}, "authored.yaml")
+	assert.NoError(t, err)
+	a := loaded.(*yamlAsset)
+	assert.Equal(t, "axe", a.Name)
+	assert.Equal(t, "owner", a.Owner.String)
+	assert.Equal(t, uint64(16), a.Big)
+	assert.Equal(t, 1000.0, a.Ratio)
+	assert.Equal(t, map[string]bool{"on": true, "off": false}, a.Flags)
+	assert.Equal(t, []byte{0, 1, 2}, a.Data)
+
+	_, err = parcel.YAMLToJSON([]byte("x: .inf"))
+	assert.ErrorContains(t, err, "cannot be represented in JSON")
+}
+
+func TestYAMLKeepsCommentsAndUnknownFields(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.RegisterCodec(".yaml", &parcel.YAMLCodec{})
+	p.AddType(&extrasAsset{})
+	store.WriteFile("a.yaml", []byte(`# tuned by design
+Type: '*parcel_test.extrasAsset'
+Parent: ""
+Obj:
+  # shown in the shop
+  Name: axe # keep it short
+  Items:
+    - Name: i0
+      # added by the new editor
+      Color: red
+  Lookup: {}
+`))
+	obj, err := p.Load(&extrasAsset{}, "a.yaml")
+	assert.NoError(t, err)
+	obj.(*extrasAsset).Name = "hatchet"
+	assert.NoError(t, p.Save(obj))
+	data, _ := store.ReadFile("a.yaml")
+	assert.Equal(t, `# tuned by design
+Type: '*parcel_test.extrasAsset'
+Parent: ""
+Obj:
+  # shown in the shop
+  Name: hatchet # keep it short
+  Items:
+    - Name: i0
+      # added by the new editor
+      Color: red
+  Lookup: {}
+`, string(data))
+}
+
+func TestYAMLCommentsFollowTheAsset(t *testing.T) {
+	p := parcel.NewParcel()
+	store := setupBasic(p, setupOpts{})
+	p.RegisterCodec(".yaml", &parcel.YAMLCodec{})
+	store.WriteFile("a.yaml", []byte(`Type: '*parcel_test.testType'
+Obj:
+  String: a # the name
+`))
+	obj, err := p.Load(&testType{}, "a.yaml")
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.Move("a.yaml", "b.yaml"))
+	data, _ := store.ReadFile("b.yaml")
+	assert.Contains(t, string(data), "String: a # the name", "comments move with the asset")
+
+	// a new asset at the old path does not get the old comments
+	assert.NoError(t, p.SetSavePath(&testType{String: "new"}, "a.yaml"))
+	data, _ = store.ReadFile("a.yaml")
+	assert.NotContains(t, string(data), "#")
+
+	assert.NoError(t, p.Delete("b.yaml"))
+	assert.NoError(t, p.SetSavePath(obj, "b.yaml"))
+	data, _ = store.ReadFile("b.yaml")
+	assert.NotContains(t, string(data), "#", "deleting forgets the comments")
+}
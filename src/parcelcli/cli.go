@@ -0,0 +1,325 @@
+// Package parcelcli implements the parcel command line tool.  It is a
+// library so that projects can build their own copy of the tool with
+// their asset types registered, which lets validate check every Type
+// header.  The stock cmd/parcel has no types registered and skips that
+// check.
+package parcelcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Bradbev/parcel/src/parcel"
+)
+
+const usage = `usage: parcel [flags] <command> [arguments]
+
+commands:
+  ls [prefix]          list assets with their Type and Parent headers
+  cat <asset>          pretty print an asset
+  validate             check that assets parse, references resolve and types are known
+  deps [asset]         print the reference graph, or the references of one asset
+  grep <value>         find assets with a field containing value
+  grep <Field>=<value> find assets where the field at a path equals value
+  schema [type...]     print a JSON Schema for the named or all registered types
+`
+
+type command struct {
+	out    io.Writer
+	errOut io.Writer
+	p      *parcel.Parcel
+	// assets that could not be read by deps or grep
+	unreadable int
+}
+
+// Run runs the command described by args against the filesystems
+// registered with p and returns the exit code.
+func Run(p *parcel.Parcel, args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	c := &command{out: stdout, errOut: stderr, p: p}
+	var err error
+	switch args[0] {
+	case "ls":
+		err = c.ls(args[1:])
+	case "cat":
+		err = c.cat(args[1:])
+	case "validate":
+		var problems int
+		problems, err = c.validate()
+		if err == nil && problems > 0 {
+			err = fmt.Errorf("%d problems found", problems)
+		}
+	case "deps":
+		err = c.deps(args[1:])
+	case "grep":
+		err = c.grep(args[1:])
+	case "schema":
+		err = c.schema(args[1:])
+	case "help", "-h", "-help":
+		fmt.Fprint(stdout, usage)
+	default:
+		fmt.Fprintf(stderr, "parcel: unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+	if err == nil && c.unreadable > 0 {
+		err = fmt.Errorf("%d assets could not be read", c.unreadable)
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "parcel:", err)
+		return 1
+	}
+	return 0
+}
+
+// asset is the header and body of an asset file, decoded without
+// knowing its Go type.
+type asset struct {
+	Type   string
+	Parent string
+	Obj    any
+}
+
+func (c *command) read(path string) (*asset, error) {
+	data, err := c.p.ReadAsset(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var a asset
+	if err := dec.Decode(&a); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &a, nil
+}
+
+// skip reports an asset that could not be read and carries on, Run
+// fails once the command has finished.  Read errors already name the
+// path.
+func (c *command) skip(err error) {
+	c.unreadable++
+	fmt.Fprintln(c.errOut, "parcel:", err)
+}
+
+func (c *command) ls(args []string) error {
+	if len(args) > 1 {
+		return flag.ErrHelp
+	}
+	paths, err := c.p.Assets()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	for _, path := range paths {
+		if len(args) == 1 && !strings.HasPrefix(path, args[0]) {
+			continue
+		}
+		a, err := c.read(path)
+		if err != nil {
+			fmt.Fprintf(w, "%s\t<%v>\t\n", path, err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", path, a.Type, a.Parent)
+	}
+	return w.Flush()
+}
+
+func (c *command) cat(args []string) error {
+	if len(args) != 1 {
+		return flag.ErrHelp
+	}
+	data, err := c.p.ReadAsset(c.p.AssetPath(args[0]))
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err = buf.WriteTo(c.out)
+	return err
+}
+
+// validate prints one line per problem and returns how many it found.
+func (c *command) validate() (int, error) {
+	paths, err := c.p.Assets()
+	if err != nil {
+		return 0, err
+	}
+	checkTypes := len(c.p.TypeNames()) > 0
+	problems := 0
+	report := func(path string, msg string, args ...any) {
+		problems++
+		fmt.Fprintf(c.out, "%s: %s\n", path, fmt.Sprintf(msg, args...))
+	}
+	for _, path := range paths {
+		a, err := c.read(path)
+		if err != nil {
+			report(path, "does not parse: %v", err)
+			continue
+		}
+		switch {
+		case a.Type == "":
+			report(path, "missing Type header")
+		case checkTypes && !c.p.IsKnownType(a.Type):
+			report(path, "unknown type %s", a.Type)
+		}
+		for _, ref := range c.references(a.Obj) {
+			if _, err := c.p.ReadFile(ref); err != nil {
+				report(path, "reference %s does not resolve", ref)
+			}
+		}
+	}
+	return problems, nil
+}
+
+func (c *command) deps(args []string) error {
+	switch len(args) {
+	case 0:
+		paths, err := c.p.Assets()
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			a, err := c.read(path)
+			if err != nil {
+				c.skip(err)
+				continue
+			}
+			for _, ref := range c.references(a.Obj) {
+				fmt.Fprintf(c.out, "%s -> %s\n", path, ref)
+			}
+		}
+		return nil
+	case 1:
+		return c.depTree(c.p.AssetPath(args[0]), 0, map[string]bool{})
+	}
+	return flag.ErrHelp
+}
+
+// depTree prints path and everything it references, indented by depth.
+func (c *command) depTree(path string, depth int, visiting map[string]bool) error {
+	indent := strings.Repeat("  ", depth)
+	if visiting[path] {
+		fmt.Fprintf(c.out, "%s%s (cycle)\n", indent, path)
+		return nil
+	}
+	a, err := c.read(path)
+	if err != nil {
+		if depth == 0 {
+			return err
+		}
+		fmt.Fprintf(c.out, "%s%s (missing)\n", indent, path)
+		return nil
+	}
+	fmt.Fprintf(c.out, "%s%s\n", indent, path)
+	visiting[path] = true
+	defer delete(visiting, path)
+	for _, ref := range c.references(a.Obj) {
+		if err := c.depTree(ref, depth+1, visiting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// references returns the asset paths referenced from v, in the order
+// they appear.  Without the Go types any string ending in the extension
+// of a registered codec is treated as a reference.  With extensions
+// turned off, strings naming an existing asset are.
+func (c *command) references(v any) []string {
+	var refs []string
+	walk(v, "", func(_ string, leaf any) {
+		s, ok := leaf.(string)
+		if !ok || slices.Contains(refs, s) {
+			return
+		}
+		if c.p.IsAssetPath(s) {
+			refs = append(refs, s)
+		} else if c.p.Extension() == "" {
+			if _, err := c.p.ReadFile(s); err == nil {
+				refs = append(refs, s)
+			}
+		}
+	})
+	return refs
+}
+
+func (c *command) grep(args []string) error {
+	if len(args) != 1 {
+		return flag.ErrHelp
+	}
+	field, value, exact := strings.Cut(args[0], "=")
+	if !exact {
+		value = field
+	}
+	paths, err := c.p.Assets()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		a, err := c.read(path)
+		if err != nil {
+			c.skip(err)
+			continue
+		}
+		walk(a.Obj, "", func(fieldPath string, leaf any) {
+			s := fmt.Sprint(leaf)
+			if (exact && fieldPath == field && s == value) || (!exact && strings.Contains(s, value)) {
+				fmt.Fprintf(c.out, "%s: %s = %s\n", path, fieldPath, s)
+			}
+		})
+	}
+	return nil
+}
+
+func (c *command) schema(args []string) error {
+	data, err := c.p.JSONSchemaForTypes(args...)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.out, "%s\n", data)
+	return err
+}
+
+// walk calls fn for every leaf value in v with its field path, such as
+// "Weapons[2].Damage".  Object keys are visited in sorted order.
+func walk(v any, path string, fn func(path string, leaf any)) {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			walk(v[k], child, fn)
+		}
+	case []any:
+		for i, e := range v {
+			walk(e, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case nil:
+	default:
+		fn(path, v)
+	}
+}
@@ -0,0 +1,132 @@
+package parcelcli_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Bradbev/parcel/src/parcel"
+	"github.com/Bradbev/parcel/src/parcelcli"
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	Name   string
+	Damage int
+}
+
+type holder struct {
+	Name string
+	Best *item
+}
+
+func setup(t *testing.T) (*parcel.Parcel, *parcel.MemoryFS) {
+	p := parcel.NewParcel()
+	base := parcel.NewMemoryFS()
+	mods := parcel.NewMemoryFS()
+	p.RegisterFS(mods, 0)
+	p.RegisterFS(base, 10)
+	p.RegisterWriteableFS(base)
+	p.AddType(&item{})
+	p.AddType(&holder{})
+
+	sword := &item{Name: "sword", Damage: 5}
+	assert.NoError(t, p.SetSavePath(sword, "items/sword"))
+	assert.NoError(t, p.SetSavePath(&holder{Name: "chest", Best: sword}, "chest"))
+	// the mod layer overrides the sword
+	mods.WriteFile("items/sword.parcel", []byte(`{"Type":"*parcelcli_test.item","Parent":"","Obj":{"Name":"sword","Damage":9}}`))
+	return p, base
+}
+
+func run(p *parcel.Parcel, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := parcelcli.Run(p, args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestLsAndCat(t *testing.T) {
+	p, _ := setup(t)
+	code, out, _ := run(p, "ls")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "chest.parcel        *parcelcli_test.holder  \nitems/sword.parcel  *parcelcli_test.item    \n", out)
+
+	code, out, _ = run(p, "cat", "items/sword")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, `"Damage": 9`, "cat reads the highest priority layer")
+}
+
+func TestValidate(t *testing.T) {
+	p, base := setup(t)
+	code, out, _ := run(p, "validate")
+	assert.Equal(t, 0, code)
+	assert.Empty(t, out)
+
+	base.WriteFile("broken.parcel", []byte(`{"Type":`))
+	base.WriteFile("dangling.parcel", []byte(`{"Type":"*parcelcli_test.holder","Obj":{"Best":"gone.parcel"}}`))
+	base.WriteFile("unknown.parcel", []byte(`{"Type":"*other.thing","Obj":{}}`))
+	code, out, errOut := run(p, "validate")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "broken.parcel: does not parse")
+	assert.Contains(t, out, "dangling.parcel: reference gone.parcel does not resolve\n")
+	assert.Contains(t, out, "unknown.parcel: unknown type *other.thing\n")
+	assert.Equal(t, "parcel: 3 problems found\n", errOut)
+}
+
+func TestDeps(t *testing.T) {
+	p, base := setup(t)
+	base.WriteFile("loop.parcel", []byte(`{"Type":"*parcelcli_test.holder","Obj":{"Name":"loop.parcel","Best":"chest.parcel"}}`))
+	_, out, _ := run(p, "deps")
+	assert.Equal(t, "chest.parcel -> items/sword.parcel\nloop.parcel -> chest.parcel\nloop.parcel -> loop.parcel\n", out)
+
+	_, out, _ = run(p, "deps", "loop")
+	assert.Equal(t, "loop.parcel\n  chest.parcel\n    items/sword.parcel\n  loop.parcel (cycle)\n", out)
+
+	// a broken asset is reported and the rest are still listed
+	base.WriteFile("broken.parcel", []byte(`{"Type":`))
+	code, out, errOut := run(p, "deps")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "loop.parcel -> chest.parcel\n")
+	assert.Equal(t, "parcel: broken.parcel: unexpected EOF\nparcel: 1 assets could not be read\n", errOut)
+}
+
+func TestGrep(t *testing.T) {
+	p, base := setup(t)
+	_, out, _ := run(p, "grep", "swo")
+	assert.Equal(t, "chest.parcel: Best = items/sword.parcel\nitems/sword.parcel: Name = sword\n", out)
+
+	_, out, _ = run(p, "grep", "Damage=9")
+	assert.Equal(t, "items/sword.parcel: Damage = 9\n", out)
+
+	base.WriteFile("broken.parcel", []byte(`{"Type":`))
+	code, out, errOut := run(p, "grep", "Damage=9")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "items/sword.parcel: Damage = 9\n", out)
+	assert.Equal(t, "parcel: broken.parcel: unexpected EOF\nparcel: 1 assets could not be read\n", errOut)
+}
+
+func TestUsage(t *testing.T) {
+	p, _ := setup(t)
+	code, _, errOut := run(p)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, errOut, "usage: parcel")
+	code, _, _ = run(p, "cat")
+	assert.Equal(t, 2, code)
+	code, _, _ = run(p, "bogus")
+	assert.Equal(t, 2, code)
+}
+
+func TestSchema(t *testing.T) {
+	p, _ := setup(t)
+	code, out, _ := run(p, "schema")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, `"oneOf"`)
+	assert.Contains(t, out, `"const": "*parcelcli_test.holder"`)
+	assert.Contains(t, out, `"const": "*parcelcli_test.item"`)
+
+	code, out, _ = run(p, "schema", "*parcelcli_test.item")
+	assert.Equal(t, 0, code)
+	assert.NotContains(t, out, `"oneOf"`)
+
+	code, _, errOut := run(p, "schema", "*other.thing")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "*other.thing")
+}